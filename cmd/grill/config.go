@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configName is the name of the project configuration file.
+//
+// The file consists of "option = value" lines, where options are named like
+// the command line flags. Lines starting with '#' or ';' are comments. If the
+// file has sections, only the [grill] section is used. The special "tests"
+// option lists the tests to run when none are given on the command line.
+const configName = ".grillrc"
+
+// config holds the options read from a configuration file.
+type config struct {
+	// dir is the directory of the configuration file, if there is one.
+	dir string
+	// args are the options in command line form.
+	args []string
+	// tests are the tests to run by default, relative to the config file.
+	tests []string
+}
+
+// findConfig looks for the configuration file in dir and its parents.
+// It returns an empty path if there is none.
+func findConfig(dir string) (string, error) {
+	for {
+		path := filepath.Join(dir, configName)
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfig reads the configuration file applying to the working directory.
+// The tests it lists are made relative to the working directory.
+func loadConfig() (*config, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	path, err := findConfig(wd)
+	if err != nil || path == "" {
+		return &config{}, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	cfg, err := parseConfig(f, path)
+	if err != nil {
+		return nil, err
+	}
+	cfg.dir = filepath.Dir(path)
+
+	for i, t := range cfg.tests {
+		if !filepath.IsAbs(t) {
+			t = filepath.Join(filepath.Dir(path), t)
+		}
+		if rel, err := filepath.Rel(wd, t); err == nil {
+			t = rel
+		}
+		cfg.tests[i] = t
+	}
+
+	return cfg, nil
+}
+
+// parseConfig parses a configuration file. name is used in error messages.
+func parseConfig(r io.Reader, name string) (*config, error) {
+	var (
+		cfg     config
+		section = "grill"
+		scanner = bufio.NewScanner(r)
+	)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("%s:%d: bad section header", name, n)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if section != "grill" {
+			continue
+		}
+
+		i := strings.IndexByte(line, '=')
+		if i < 0 {
+			return nil, fmt.Errorf("%s:%d: expected 'option = value'", name, n)
+		}
+		key := strings.TrimSpace(line[:i])
+		value := strings.TrimSpace(line[i+1:])
+
+		if key == "tests" {
+			tests, err := splitShellWords(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s:%d: %s", name, n, err)
+			}
+			cfg.tests = append(cfg.tests, tests...)
+			continue
+		}
+		if flags.Lookup(key) == nil {
+			return nil, fmt.Errorf("%s:%d: unknown option %q", name, n, key)
+		}
+		cfg.args = append(cfg.args, fmt.Sprintf("-%s=%s", key, value))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %s", name, err)
+	}
+
+	return &cfg, nil
+}
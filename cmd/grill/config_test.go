@@ -0,0 +1,63 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConfig(t *testing.T) {
+	const data = `# Project defaults
+shell = /bin/bash
+shell-opts = -o pipefail
+
+[other]
+shell = /bin/zsh
+
+[grill]
+; Comment
+preserve-env=true
+exclude = fixtures
+exclude = **/testdata/**
+tests = tests "more tests"
+`
+
+	cfg, err := parseConfig(strings.NewReader(data), ".grillrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantArgs := []string{
+		"-shell=/bin/bash",
+		"-shell-opts=-o pipefail",
+		"-preserve-env=true",
+		"-exclude=fixtures",
+		"-exclude=**/testdata/**",
+	}
+	if !reflect.DeepEqual(cfg.args, wantArgs) {
+		t.Errorf("bad args: got %q, want %q", cfg.args, wantArgs)
+	}
+	if want := []string{"tests", "more tests"}; !reflect.DeepEqual(cfg.tests, want) {
+		t.Errorf("bad tests: got %q, want %q", cfg.tests, want)
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := map[string]string{
+		"shell\n":        ".grillrc:1: expected 'option = value'",
+		"\nnope = 1\n":   `.grillrc:2: unknown option "nope"`,
+		"[grill\n":       ".grillrc:1: bad section header",
+		"tests = 'a b\n": ".grillrc:1: unterminated single quote",
+	}
+
+	for data, want := range tests {
+		_, err := parseConfig(strings.NewReader(data), ".grillrc")
+		if err == nil {
+			t.Errorf("%q: expected error", data)
+			continue
+		}
+		if got := err.Error(); got != want {
+			t.Errorf("%q: got error %q, want %q", data, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/echlebek/glob"
+)
+
+// findTestSuites returns the paths of test suites named by args.
+//
+// Directories are searched recursively for *.t files, in lexical order,
+// skipping hidden directories. Other files, such as Markdown documents,
+// are only run when named explicitly. Paths matching any of the exclude glob
+// patterns, either as a whole or by their base name, are left out.
+func findTestSuites(args []string, exclude []string) ([]string, error) {
+	var paths []string
+
+	for _, a := range args {
+		info, err := os.Stat(a)
+		if err != nil {
+			return nil, err
+		}
+		if excluded(a, exclude) {
+			continue
+		}
+		if !info.IsDir() {
+			paths = append(paths, a)
+			continue
+		}
+
+		err = filepath.WalkDir(a, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == a {
+				return nil
+			}
+			if d.IsDir() {
+				if strings.HasPrefix(d.Name(), ".") || excluded(path, exclude) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(path) == ".t" && !excluded(path, exclude) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
+
+func excluded(path string, patterns []string) bool {
+	path = filepath.Clean(path)
+	for _, p := range patterns {
+		for _, s := range []string{path, filepath.Base(path)} {
+			if ok, err := glob.Match(p, s); err == nil && ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseShard parses a shard specification of the form INDEX/TOTAL,
+// where INDEX counts from 1.
+func parseShard(s string) (index, total int, err error) {
+	i := strings.IndexByte(s, '/')
+	if i < 0 {
+		return 0, 0, errors.New("expected INDEX/TOTAL")
+	}
+	if index, err = strconv.Atoi(s[:i]); err != nil {
+		return 0, 0, fmt.Errorf("bad index: %s", err)
+	}
+	if total, err = strconv.Atoi(s[i+1:]); err != nil {
+		return 0, 0, fmt.Errorf("bad total: %s", err)
+	}
+	if total < 1 || index < 1 || index > total {
+		return 0, 0, errors.New("expected 1 <= INDEX <= TOTAL")
+	}
+	return index, total, nil
+}
+
+// shardPaths returns the paths that belong to the index-th of total shards.
+//
+// Paths are assigned to shards by their hash, so the assignment
+// is stable regardless of what other paths are being sharded.
+func shardPaths(paths []string, index, total int) []string {
+	var shard []string
+	for _, p := range paths {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(filepath.ToSlash(filepath.Clean(p))))
+		if int(h.Sum32()%uint32(total)) == index-1 {
+			shard = append(shard, p)
+		}
+	}
+	return shard
+}
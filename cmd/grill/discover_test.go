@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestShardPaths(t *testing.T) {
+	var paths []string
+	for i := 0; i < 100; i++ {
+		paths = append(paths, fmt.Sprintf("tests/%d.t", i))
+	}
+
+	const total = 8
+	seen := make(map[string]int)
+	for index := 1; index <= total; index++ {
+		shard := shardPaths(paths, index, total)
+		if len(shard) == 0 {
+			t.Errorf("shard %d/%d is empty", index, total)
+		}
+		for _, p := range shard {
+			seen[p]++
+		}
+
+		// Assignment doesn't depend on the other paths.
+		if got := shardPaths(append([]string{"other.t"}, shard...), index, total); !reflect.DeepEqual(got[len(got)-len(shard):], shard) {
+			t.Errorf("shard %d/%d is not stable: got %q, want %q", index, total, got, shard)
+		}
+	}
+
+	for _, p := range paths {
+		if seen[p] != 1 {
+			t.Errorf("%s is in %d shards, want 1", p, seen[p])
+		}
+	}
+}
+
+func TestParseShard(t *testing.T) {
+	if index, total, err := parseShard("2/8"); err != nil || index != 2 || total != 8 {
+		t.Errorf("bad shard: got %d/%d (%v), want 2/8", index, total, err)
+	}
+	for _, s := range []string{"", "2", "0/8", "9/8", "1/0", "a/8", "1/b"} {
+		if _, _, err := parseShard(s); err == nil {
+			t.Errorf("%q: expected error", s)
+		}
+	}
+}
@@ -3,10 +3,103 @@ package main
 import (
 	"errors"
 	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var flags = flag.NewFlagSet("grill", flag.PanicOnError)
 
+// stringList is a flag that may be given more than once.
+type stringList struct {
+	values []string
+	// replace tells that the next value starts the list over.
+	replace bool
+}
+
+func (l *stringList) String() string {
+	return strings.Join(l.values, ",")
+}
+
+func (l *stringList) Set(s string) error {
+	if l.replace {
+		l.values, l.replace = nil, false
+	}
+	l.values = append(l.values, s)
+	return nil
+}
+
+func (l *stringList) Reset() {
+	*l = stringList{}
+}
+
+// startOptionSource is called before parsing the options that come from
+// another source, so that lists given there replace those given before,
+// the same way other options do.
+func startOptionSource() {
+	flags.VisitAll(func(f *flag.Flag) {
+		if l, ok := f.Value.(*stringList); ok {
+			l.replace = true
+		}
+	})
+}
+
+// seedValue is an int64 flag that tells whether it has been set,
+// since any value, including 0, is a valid seed.
+type seedValue struct {
+	value int64
+	set   bool
+}
+
+func (f *seedValue) String() string {
+	if !f.set {
+		return ""
+	}
+	return strconv.FormatInt(f.value, 10)
+}
+
+func (f *seedValue) Set(s string) error {
+	v, err := strconv.ParseInt(s, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		return errors.New("value out of range")
+	}
+	if err != nil {
+		return errors.New("parse error")
+	}
+	f.value, f.set = v, true
+	return nil
+}
+
+func (f *seedValue) Reset() {
+	*f = seedValue{}
+}
+
+// defaultFlags returns a flag set of the same options as flags, which
+// reports errors instead of printing usage and panicking. It parses
+// the options that come from the configuration file and GRILL_OPTS.
+func defaultFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("grill", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	flags.VisitAll(func(f *flag.Flag) {
+		fs.Var(f.Value, f.Name, f.Usage)
+	})
+	return fs
+}
+
+func listFlag(name, usage string) *stringList {
+	l := new(stringList)
+	flags.Var(l, name, usage)
+	return l
+}
+
+func seedFlag(name, usage string) *seedValue {
+	f := new(seedValue)
+	flags.Var(f, name, usage)
+	return f
+}
+
 var opts = struct {
 	version     *bool
 	quiet       *bool
@@ -22,29 +115,176 @@ var opts = struct {
 	xunitFile   *string
 	indent      *int
 	ctxLen      *int
+	jobs        *int
+	timeout     *time.Duration
+	cmdTimeout  *time.Duration
+	exclude     *stringList
+	run         *string
+	failfast    *bool
+	maxFailures *int
+	shard       *string
+	failed      *bool
+	stateDir    *string
+	watch       *bool
+	watchDirs   *stringList
+	watchPoll   *time.Duration
+	count       *int
+	shuffle     *bool
+	shuffleSeed *seedValue
+	json        *bool
+	tap         *bool
 }{
 	version:     flags.Bool("version", false, "show version information and exit"),
 	quiet:       flags.Bool("quiet", false, "don't print diffs"),
 	verbose:     flags.Bool("verbose", false, "show filenames and test status"),
-	interactive: flags.Bool("interactive", false, "interactively merge changed test output (unsupported)"),
-	debug:       flags.Bool("debug", false, "write script output directly to the terminal (unsupported)"),
-	yes:         flags.Bool("yes", false, "answer yes to all questions (unsupported)"),
-	no:          flags.Bool("no", false, "answer no to all questions (unsupported)"),
+	interactive: flags.Bool("interactive", false, "interactively merge changed test output"),
+	debug:       flags.Bool("debug", false, "write script output directly to the terminal"),
+	yes:         flags.Bool("yes", false, "answer yes to all questions, merging all changed test output"),
+	no:          flags.Bool("no", false, "answer no to all questions and don't write .err files"),
 	preserveEnv: flags.Bool("preserve-env", false, "don't reset common environment variables"),
 	keepTmpdir:  flags.Bool("keep-tmpdir", false, "keep temporary directories"),
 	shell:       flags.String("shell", "/bin/sh", "shell to use for running tests"),
-	shellOpts:   flags.String("shell-opts", "", "arguments to invoke shell with (unsupported)"),
-	xunitFile:   flags.String("xunit-file", "", "path to write xUnit XML output (unsupported)"),
-	indent:      flags.Int("indent", 2, "number of spaces to use for indentation (unsupported)"),
+	shellOpts:   flags.String("shell-opts", "", "arguments to invoke shell with, split using shell quoting rules"),
+	xunitFile:   flags.String("xunit-file", "", "path to write xUnit XML output"),
+	indent:      flags.Int("indent", 2, "number of spaces to use for indentation"),
 	ctxLen:      flags.Int("context-lines", 3, "number of diff context lines to leave around each change"),
+	jobs:        flags.Int("jobs", 1, "number of test suites to run concurrently"),
+	timeout:     flags.Duration("timeout", 0, "maximum time each test suite may run for (0 means no limit)"),
+	cmdTimeout:  flags.Duration("command-timeout", 0, "maximum time each test command may run for (0 means no limit)"),
+	run:         flags.String("run", "", "run only suites whose path, or commands whose doc, match the regular expression"),
+	failfast:    flags.Bool("failfast", false, "stop after the first failed test suite"),
+	maxFailures: flags.Int("max-failures", 0, "stop after this many failed test suites (0 means no limit)"),
+	shard:       flags.String("shard", "", "run only the INDEX/TOTAL share of test suites, split by hashed path"),
+	failed:      flags.Bool("failed", false, "run only the test suites that failed last time, or all if there is no record"),
+	stateDir:    flags.String("state-dir", "", "directory to record test suite outcomes in (default: that of .grillrc)"),
+	watch:       flags.Bool("watch", false, "keep running, rerunning test suites as they change"),
+	watchDirs:   listFlag("watch-dir", "directory whose changes rerun all test suites in -watch mode (may be repeated)"),
+	watchPoll:   flags.Duration("watch-interval", time.Second, "how often to check for changes in -watch mode"),
+	count:       flags.Int("count", 1, "run each test suite this many times, reporting output that varies between runs"),
+	shuffle:     flags.Bool("shuffle", false, "run test suites in random order"),
+	shuffleSeed: seedFlag("shuffle-seed", "`seed` for -shuffle, to reproduce an earlier order (implies -shuffle)"),
+	json:        flags.Bool("json", false, "print a stream of JSON events instead of the usual output"),
+	tap:         flags.Bool("tap", false, "print a Test Anything Protocol report instead of the usual output"),
+	exclude:     listFlag("exclude", "glob pattern of paths to leave out when searching for tests (may be repeated)"),
+}
+
+// resetOptions restores all flags to their default values so that
+// Main can be invoked more than once.
+func resetOptions() {
+	flags.VisitAll(func(f *flag.Flag) {
+		if r, ok := f.Value.(interface{ Reset() }); ok {
+			r.Reset()
+			return
+		}
+		_ = f.Value.Set(f.DefValue)
+	})
 }
 
 func validateOptions() error {
 	if *opts.yes && *opts.no {
 		return errors.New("use of mutually exclusive -yes and -no")
 	}
+	if *opts.json && *opts.tap {
+		return errors.New("use of mutually exclusive -json and -tap")
+	}
+	if *opts.debug || *opts.interactive || *opts.yes || *opts.no {
+		if *opts.json {
+			return errors.New("-json can't be combined with -debug, -interactive, -yes or -no")
+		}
+		if *opts.tap {
+			return errors.New("-tap can't be combined with -debug, -interactive, -yes or -no")
+		}
+	}
 	if *opts.indent < 1 {
 		return errors.New("-indent must be >= 1")
 	}
+	if *opts.jobs < 1 {
+		return errors.New("-jobs must be >= 1")
+	}
+	if *opts.count < 1 {
+		return errors.New("-count must be >= 1")
+	}
+	if *opts.maxFailures < 0 {
+		return errors.New("-max-failures must be >= 0")
+	}
+	if *opts.shard != "" {
+		if _, _, err := parseShard(*opts.shard); err != nil {
+			return fmt.Errorf("invalid -shard: %s", err)
+		}
+	}
+	if *opts.watchPoll <= 0 {
+		return errors.New("-watch-interval must be > 0")
+	}
+	if *opts.timeout < 0 {
+		return errors.New("-timeout must be >= 0")
+	}
+	if *opts.cmdTimeout < 0 {
+		return errors.New("-command-timeout must be >= 0")
+	}
 	return nil
 }
+
+// splitShellWords splits s into words the way a POSIX shell would, honoring
+// single quotes, double quotes and backslash escapes. No expansions are done.
+func splitShellWords(s string) ([]string, error) {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+			continue
+		case c == '\\':
+			i++
+			if i == len(s) {
+				return nil, errors.New("trailing backslash")
+			}
+			if s[i] == '\n' {
+				// Line continuation
+				continue
+			}
+			word.WriteByte(s[i])
+		case c == '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, errors.New("unterminated single quote")
+			}
+			word.WriteString(s[i+1 : i+1+end])
+			i += end + 1
+		case c == '"':
+			for i++; ; i++ {
+				if i == len(s) {
+					return nil, errors.New("unterminated double quote")
+				}
+				if s[i] == '"' {
+					break
+				}
+				// Backslash only escapes these characters within double quotes.
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte("$`\"\\\n", s[i+1]) >= 0 {
+					i++
+					if s[i] == '\n' {
+						continue
+					}
+				}
+				word.WriteByte(s[i])
+			}
+		default:
+			word.WriteByte(c)
+		}
+		inWord = true
+	}
+
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
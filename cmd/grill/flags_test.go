@@ -0,0 +1,91 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+		err  bool
+	}{
+		{in: "", want: nil},
+		{in: "  ", want: nil},
+		{in: "-x", want: []string{"-x"}},
+		{in: " -o  pipefail ", want: []string{"-o", "pipefail"}},
+		{in: `-c 'echo "a b"'`, want: []string{"-c", `echo "a b"`}},
+		{in: `"a \"b\" \$c \d"`, want: []string{`a "b" $c \d`}},
+		{in: `a\ b c\\`, want: []string{"a b", `c\`}},
+		{in: "a \\\n b", want: []string{"a", "b"}},
+		{in: `''`, want: []string{""}},
+		{in: `x'y'"z"`, want: []string{"xyz"}},
+		{in: `'a`, err: true},
+		{in: `"a`, err: true},
+		{in: `a\`, err: true},
+	}
+
+	for _, test := range tests {
+		got, err := splitShellWords(test.in)
+		if test.err {
+			if err == nil {
+				t.Errorf("%q: expected error, got %q", test.in, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.in, got, test.want)
+		}
+	}
+}
+
+func TestSeedValue(t *testing.T) {
+	var seed seedValue
+	if seed.set {
+		t.Fatal("seed set without a value")
+	}
+	if err := seed.Set("0"); err != nil {
+		t.Fatal(err)
+	}
+	if !seed.set || seed.value != 0 {
+		t.Errorf("bad seed: got %+v, want a set seed of 0", seed)
+	}
+	if err := seed.Set("x"); err == nil {
+		t.Error("expected error")
+	}
+	seed.Reset()
+	if seed.set {
+		t.Error("seed still set after reset")
+	}
+}
+
+func TestStringListSources(t *testing.T) {
+	var l stringList
+	for _, s := range []string{"a", "b"} {
+		if err := l.Set(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(l.values, want) {
+		t.Errorf("bad list: got %q, want %q", l.values, want)
+	}
+
+	// Lists from a later source replace earlier ones,
+	// and are left alone if the source doesn't give any.
+	l.replace = true
+	if err := l.Set("c"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"c"}; !reflect.DeepEqual(l.values, want) {
+		t.Errorf("bad replaced list: got %q, want %q", l.values, want)
+	}
+	l.replace = true
+	if want := []string{"c"}; !reflect.DeepEqual(l.values, want) {
+		t.Errorf("bad kept list: got %q, want %q", l.values, want)
+	}
+}
@@ -1,18 +1,26 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"io"
 	"log"
+	"math/rand"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/echlebek/grill/internal/grill"
+	"github.com/echlebek/grill"
 )
 
 const grillVersion = "dev"
 
 func main() {
-	os.Exit(Main(os.Args[1:], os.Stdout, os.Stderr))
+	os.Exit(Main(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
 }
 
 func readTestSuite(path string) (ts *grill.TestSuite, err error) {
@@ -33,7 +41,10 @@ func readTestSuite(path string) (ts *grill.TestSuite, err error) {
 		}
 	}()
 
-	r := grill.NewReader(f)
+	r := grill.NewReader(f, *opts.indent)
+	if filepath.Ext(path) == ".md" {
+		r = grill.NewMarkdownReader(f)
+	}
 	var (
 		t     grill.Test
 		tests []grill.Test
@@ -44,13 +55,189 @@ func readTestSuite(path string) (ts *grill.TestSuite, err error) {
 		tests = append(tests, t)
 	}
 	if err != io.EOF && err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%s: %s", path, err)
 	}
 
 	return &grill.TestSuite{Tests: tests, Name: path}, nil
 }
 
-func Main(a []string, stdout, stderr io.Writer) int {
+func writeXUnitFile(path string, suites []*grill.TestSuite) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("couldn't write xunit file: %s", err)
+	}
+
+	defer func() {
+		if fErr := f.Close(); fErr != nil && err == nil {
+			err = fmt.Errorf("couldn't write xunit file: %s", fErr)
+		}
+	}()
+
+	return grill.WriteXUnit(f, suites, *opts.ctxLen, *opts.indent)
+}
+
+// runSuites runs suites using a pool of jobs workers, count times each.
+//
+// The returned channels are indexed like suites and each receives the result
+// of running the corresponding suite, which lets the caller report progress in
+// argument order regardless of the order in which suites complete. Each channel
+// is closed once its suite has finished, or without a result if the suite was
+// never started.
+//
+// No more suites are started once maxFailures suites have failed, if it is
+// positive, once ctx.Cancel is closed, or once the returned stop function has
+// been called. Suites that have already started are left to finish, unless
+// they are cancelled.
+//
+// If start isn't nil, it is called from the worker just before a suite starts
+// running.
+func runSuites(suites []*grill.TestSuite, ctx grill.TestContext, jobs, count, maxFailures int, start func(*grill.TestSuite)) ([]chan error, func()) {
+	results := make([]chan error, len(suites))
+	for i := range results {
+		results[i] = make(chan error, 1)
+	}
+
+	var (
+		stopCh   = make(chan struct{})
+		stopOnce sync.Once
+		stop     = func() { stopOnce.Do(func() { close(stopCh) }) }
+		failures int32
+	)
+
+	if ctx.Cancel != nil {
+		go func() {
+			select {
+			case <-ctx.Cancel:
+				stop()
+			case <-stopCh:
+			}
+		}()
+	}
+
+	// stopped reports whether stop has been called, without blocking.
+	stopped := func() bool {
+		select {
+		case <-stopCh:
+			return true
+		default:
+			return false
+		}
+	}
+
+	queue := make(chan int)
+	go func() {
+		defer close(queue)
+		for i := range suites {
+			if stopped() {
+				for _, r := range results[i:] {
+					close(r)
+				}
+				return
+			}
+			select {
+			case queue <- i:
+			case <-stopCh:
+				for _, r := range results[i:] {
+					close(r)
+				}
+				return
+			}
+		}
+	}()
+
+	for j := 0; j < jobs; j++ {
+		go func() {
+			for i := range queue {
+				// The queue may hand out a suite after stop has been called.
+				if stopped() {
+					close(results[i])
+					continue
+				}
+				if start != nil {
+					start(suites[i])
+				}
+				err := suites[i].RunCount(ctx, count)
+				if err == nil && suites[i].Failed() && maxFailures > 0 &&
+					atomic.AddInt32(&failures, 1) >= int32(maxFailures) {
+					stop()
+				}
+				results[i] <- err
+				close(results[i])
+			}
+		}()
+	}
+
+	return results, stop
+}
+
+// promptMerge writes the diff of a failed suite to w and asks whether
+// the changes should be merged back into the test file.
+//
+// The question is answered automatically when -yes or -no is set.
+func promptMerge(suite *grill.TestSuite, in *bufio.Reader, w io.Writer) (bool, error) {
+	if err := suite.WriteDiff(w, *opts.ctxLen, *opts.indent); err != nil {
+		return false, err
+	}
+	if _, err := fmt.Fprint(w, "Accept this change? [yN] "); err != nil {
+		return false, err
+	}
+	if *opts.yes {
+		_, err := fmt.Fprintln(w, "y")
+		return true, err
+	}
+	if *opts.no {
+		_, err := fmt.Fprintln(w, "n")
+		return false, err
+	}
+	answer, err := in.ReadString('\n')
+	if err == io.EOF {
+		_, err = fmt.Fprintln(w)
+	}
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
+
+// parseDefaults parses the options from the configuration file
+// and the GRILL_OPTS environment variable, in that order.
+func parseDefaults(cfg *config) error {
+	envArgs, err := splitShellWords(os.Getenv("GRILL_OPTS"))
+	if err != nil {
+		return fmt.Errorf("invalid GRILL_OPTS: %s", err)
+	}
+
+	fs := defaultFlags()
+	if err := fs.Parse(cfg.args); err != nil {
+		return fmt.Errorf("%s: %s", filepath.Join(cfg.dir, configName), err)
+	}
+	startOptionSource()
+	if err := fs.Parse(envArgs); err != nil {
+		return fmt.Errorf("invalid GRILL_OPTS: %s", err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("invalid GRILL_OPTS: unexpected argument %q", fs.Arg(0))
+	}
+	return nil
+}
+
+func Main(a []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	resetOptions()
+
+	// Configuration file and GRILL_OPTS provide defaults
+	// for the command line options.
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if err := parseDefaults(cfg); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	startOptionSource()
 	if err := flags.Parse(a); err != nil {
 		fmt.Fprintln(stderr, err.Error())
 		return 2
@@ -63,7 +250,10 @@ func Main(a []string, stdout, stderr io.Writer) int {
 
 	args := flags.Args()
 	if len(args) == 0 {
-		fmt.Fprint(stderr, "Usage: grill [OPTIONS] TESTS...\n")
+		args = cfg.tests
+	}
+	if len(args) == 0 {
+		fmt.Fprint(stderr, "Usage: grill [OPTIONS] TESTS|DIRS...\n")
 		return 2
 	}
 
@@ -72,11 +262,22 @@ func Main(a []string, stdout, stderr io.Writer) int {
 		return 2
 	}
 
-	context, err := grill.DefaultTestContext(*opts.shell, *opts.preserveEnv)
+	shellOpts, err := splitShellWords(*opts.shellOpts)
+	if err != nil {
+		fmt.Fprintf(stderr, "invalid -shell-opts: %s\n", err)
+		return 2
+	}
+
+	context, err := grill.DefaultTestContext(*opts.shell, shellOpts, *opts.preserveEnv)
 	if err != nil {
 		log.Println(err)
 		return 1
 	}
+	context.Debug = *opts.debug
+	context.Stdout = stdout
+	context.Stderr = stderr
+	context.Timeout = *opts.timeout
+	context.CommandTimeout = *opts.cmdTimeout
 
 	defer func() {
 		if *opts.keepTmpdir {
@@ -88,28 +289,230 @@ func Main(a []string, stdout, stderr io.Writer) int {
 		}
 	}()
 
+	paths, err := testPaths(args)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	// Outcomes are only recorded at a known location,
+	// rather than wherever grill happens to run.
+	var outcomes *state
+	stateDir := *opts.stateDir
+	if stateDir == "" {
+		stateDir = cfg.dir
+	}
+	if stateDir != "" {
+		if outcomes, err = loadState(stateDir); err != nil {
+			log.Println(err)
+			return 1
+		}
+	}
+
+	if *opts.failed && outcomes == nil {
+		fmt.Fprintf(stderr, "-failed needs -state-dir or a %s file to record outcomes\n", configName)
+		return 2
+	}
+
+	var run *regexp.Regexp
+	if *opts.run != "" {
+		if run, err = regexp.Compile(*opts.run); err != nil {
+			fmt.Fprintf(stderr, "invalid -run: %s\n", err)
+			return 2
+		}
+	}
+
+	seed := opts.shuffleSeed.value
+	if !opts.shuffleSeed.set {
+		seed = time.Now().UnixNano()
+	}
+
+	s := &session{
+		shuffle:  *opts.shuffle || opts.shuffleSeed.set,
+		seed:     seed,
+		context:  context,
+		run:      run,
+		in:       bufio.NewReader(stdin),
+		stdout:   stdout,
+		outcomes: outcomes,
+	}
+
+	if *opts.watch {
+		return s.watch(args, paths)
+	}
+	return s.runTests(s.context, s.selectFailed(paths))
+}
+
+// session holds the state shared by the test runs of a single grill invocation.
+type session struct {
+	shuffle  bool
+	seed     int64
+	context  grill.TestContext
+	run      *regexp.Regexp
+	in       *bufio.Reader
+	stdout   io.Writer
+	outcomes *state
+}
+
+// testPaths returns the paths of the suites selected by args and the options.
+func testPaths(args []string) ([]string, error) {
+	paths, err := findTestSuites(args, opts.exclude.values)
+	if err != nil {
+		return nil, err
+	}
+
+	if *opts.shard != "" {
+		// Already validated.
+		index, total, _ := parseShard(*opts.shard)
+		paths = shardPaths(paths, index, total)
+	}
+
+	return paths, nil
+}
+
+// selectFailed returns the paths of the suites that didn't pass the last
+// time they ran when -failed is set, and all of paths otherwise.
+func (s *session) selectFailed(paths []string) []string {
+	if !*opts.failed {
+		return paths
+	}
+	var failed []string
+	for _, p := range paths {
+		if s.outcomes.failed(p) {
+			failed = append(failed, p)
+		}
+	}
+	return failed
+}
+
+// cancelled reports whether the runs of context have been cancelled.
+func cancelled(context grill.TestContext) bool {
+	select {
+	case <-context.Cancel:
+		return true
+	default:
+		return false
+	}
+}
+
+// runTests runs the suites at paths within context and reports the results.
+// It returns the exit code for the run.
+func (s *session) runTests(context grill.TestContext, paths []string) int {
 	var (
 		rc     int
 		suites []*grill.TestSuite
+		stdout = s.stdout
+
+		// -yes and -no answer the interactive merge questions.
+		mergeMode = *opts.interactive || *opts.yes || *opts.no
 	)
 
-	for _, a := range args {
+	defer func() {
+		if err := s.outcomes.save(); err != nil {
+			log.Printf("couldn't record test outcomes: %s", err)
+		}
+	}()
+
+	for _, a := range paths {
 		suite, err := readTestSuite(a)
 		if err != nil {
+			s.outcomes.set(a, stateError)
 			log.Println(err)
 			return 1
 		}
-
+		if s.run != nil && !suite.Select(s.run) {
+			continue
+		}
 		suites = append(suites, suite)
+	}
+
+	if s.shuffle {
+		r := rand.New(rand.NewSource(s.seed))
+		r.Shuffle(len(suites), func(i, j int) {
+			suites[i], suites[j] = suites[j], suites[i]
+		})
+	}
+
+	maxFailures := *opts.maxFailures
+	if *opts.failfast {
+		maxFailures = 1
+	}
 
-		if err := suite.Run(context); err != nil {
+	jobs := *opts.jobs
+	if *opts.debug {
+		// Don't interleave the output of several suites.
+		jobs = 1
+	}
+
+	var (
+		events *grill.JSONWriter
+		start  func(*grill.TestSuite)
+	)
+	if *opts.json {
+		events = grill.NewJSONWriter(stdout)
+		if s.shuffle {
+			if err := events.Shuffle(s.seed); err != nil {
+				log.Println(err)
+				return 1
+			}
+		}
+		start = func(suite *grill.TestSuite) {
+			if err := events.Start(suite); err != nil {
+				log.Println(err)
+			}
+		}
+		context.CommandStarted = func(suite *grill.TestSuite, t *grill.Test, at time.Time) {
+			if err := events.Run(suite, t, at); err != nil {
+				log.Println(err)
+			}
+		}
+		context.CommandFinished = func(suite *grill.TestSuite, t *grill.Test, at time.Time) {
+			if err := events.Done(suite, t, at); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+
+	results, stop := runSuites(suites, context, jobs, *opts.count, maxFailures, start)
+
+	defer func() {
+		// Let the suites that are still running finish
+		// before their working directories are removed.
+		stop()
+		for _, r := range results {
+			for range r {
+			}
+		}
+	}()
+
+	for i, suite := range suites {
+		err, ok := <-results[i]
+		if !ok {
+			// Too many other suites failed for this one to be started.
+			suite.NotRun = true
+			if *opts.json {
+				if err := events.Write(suite); err != nil {
+					log.Println(err)
+					return 1
+				}
+			}
+			continue
+		}
+		if err != nil {
+			s.outcomes.set(suite.Name, stateError)
 			log.Println(err)
 			return 1
 		}
+		s.outcomes.set(suite.Name, suite.Status())
 
-		if *opts.verbose {
+		switch {
+		case *opts.json:
+			err = events.Write(suite)
+		case *opts.tap:
+			// The report is written once all suites have run.
+		case *opts.verbose:
 			_, err = fmt.Fprintf(stdout, "%s: %s\n", suite.Name, suite.Status())
-		} else {
+		default:
 			_, err = fmt.Fprint(stdout, suite.StatusGlyph())
 		}
 		if err != nil {
@@ -117,11 +520,49 @@ func Main(a []string, stdout, stderr io.Writer) int {
 			return 1
 		}
 
+		if suite.Flaky() {
+			rc = 1
+		}
+
 		if suite.Failed() {
 			rc = 1
-			if err := suite.WriteErr(); err != nil {
-				log.Println(err)
-				return 1
+
+			merge := false
+			switch {
+			case cancelled(context):
+				// The output of an interrupted
+				// suite is never merged.
+			case (*opts.yes || *opts.no) && *opts.quiet:
+				// The answer is known, so there's no
+				// need to show the diff and question.
+				merge = *opts.yes
+			case mergeMode:
+				if !*opts.verbose {
+					if _, err := fmt.Fprint(stdout, "\n"); err != nil {
+						log.Println(err)
+						return 1
+					}
+				}
+				if merge, err = promptMerge(suite, s.in, stdout); err != nil {
+					log.Println(err)
+					return 1
+				}
+			}
+
+			if merge {
+				if err := suite.Merge(*opts.indent); err != nil {
+					log.Println(err)
+					return 1
+				}
+				if err := suite.RemoveErr(); err != nil {
+					log.Println(err)
+					return 1
+				}
+			} else if !*opts.no {
+				if err := suite.WriteErr(*opts.indent); err != nil {
+					log.Println(err)
+					return 1
+				}
 			}
 		} else {
 			if err := suite.RemoveErr(); err != nil {
@@ -131,16 +572,43 @@ func Main(a []string, stdout, stderr io.Writer) int {
 		}
 	}
 
-	if !*opts.verbose {
-		if _, err := fmt.Fprint(stdout, "\n"); err != nil {
+	if *opts.tap {
+		report := grill.TAPOptions{
+			Shuffled: s.shuffle,
+			Seed:     s.seed,
+		}
+		if err := grill.WriteTAP(stdout, suites, report); err != nil {
+			log.Println(err)
+			return 1
+		}
+	} else if !*opts.json {
+		if !*opts.verbose {
+			if _, err := fmt.Fprint(stdout, "\n"); err != nil {
+				log.Println(err)
+				return 1
+			}
+		}
+
+		report := grill.ReportOptions{
+			ContextLines: *opts.ctxLen,
+			Indent:       *opts.indent,
+			// Diffs have already been shown when merging.
+			Quiet:    *opts.quiet || mergeMode,
+			Shard:    *opts.shard,
+			Shuffled: s.shuffle,
+			Seed:     s.seed,
+		}
+		if err := grill.WriteReport(stdout, suites, report); err != nil {
 			log.Println(err)
 			return 1
 		}
 	}
 
-	if err := grill.WriteReport(stdout, suites, *opts.ctxLen, *opts.quiet); err != nil {
-		log.Println(err)
-		return 1
+	if *opts.xunitFile != "" {
+		if err := writeXUnitFile(*opts.xunitFile, suites); err != nil {
+			log.Println(err)
+			return 1
+		}
 	}
 
 	return rc
@@ -25,6 +25,7 @@ const (
 type testCtx struct {
 	Dir    string
 	Test   *os.File
+	Stdin  *bytes.Buffer
 	Stdout *bytes.Buffer
 	Stderr *bytes.Buffer
 }
@@ -47,6 +48,7 @@ func newTestCtx(test string) (testCtx, error) {
 
 	ctx.Dir = dir
 	ctx.Test = ctest
+	ctx.Stdin = new(bytes.Buffer)
 	ctx.Stdout = new(bytes.Buffer)
 	ctx.Stderr = new(bytes.Buffer)
 
@@ -58,7 +60,7 @@ func TestGrillFail(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if got, want := Main([]string{ctx.Test.Name()}, ctx.Stdout, ctx.Stderr), 1; got != want {
+	if got, want := Main([]string{ctx.Test.Name()}, ctx.Stdin, ctx.Stdout, ctx.Stderr), 1; got != want {
 		t.Errorf("bad return code: got %d, want %d", got, want)
 	}
 
@@ -87,7 +89,7 @@ func TestGrillPass(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if got, want := Main([]string{ctx.Test.Name()}, ctx.Stdout, ctx.Stderr), 0; got != want {
+	if got, want := Main([]string{ctx.Test.Name()}, ctx.Stdin, ctx.Stdout, ctx.Stderr), 0; got != want {
 		t.Errorf("bad return code: got %d, want %d", got, want)
 	}
 
@@ -101,7 +103,7 @@ func TestGrillVersion(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	if got, want := Main([]string{"-version", "foo", "bar", "baz"}, ctx.Stdout, ctx.Stderr), 0; got != want {
+	if got, want := Main([]string{"-version", "foo", "bar", "baz"}, ctx.Stdin, ctx.Stdout, ctx.Stderr), 0; got != want {
 		t.Errorf("bad return code: got %d, want %d", got, want)
 	}
 	got := ctx.Stderr.String()
@@ -110,3 +112,57 @@ func TestGrillVersion(t *testing.T) {
 		t.Errorf("bad version: got %q, want %q", got, want)
 	}
 }
+
+func TestGrillJobs(t *testing.T) {
+	ctx, err := newTestCtx(testData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(ctx.Dir)
+
+	fail := filepath.Join(ctx.Dir, "fail.t")
+	if err := os.WriteFile(fail, []byte(failTestData), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"-jobs", "2", "-quiet", fail, ctx.Test.Name(), fail, ctx.Test.Name()}
+	if got, want := Main(args, ctx.Stdin, ctx.Stdout, ctx.Stderr), 1; got != want {
+		t.Errorf("bad return code: got %d, want %d", got, want)
+	}
+
+	if want, got := "!.!.\n# Ran 4 tests, 0 skipped, 2 failed.\n", ctx.Stdout.String(); got != want {
+		t.Errorf("bad stdout: got %q, want %q", got, want)
+	}
+}
+
+func TestGrillInteractive(t *testing.T) {
+	ctx, err := newTestCtx(failTestData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(ctx.Dir)
+
+	ctx.Stdin.WriteString("y\n")
+	if got, want := Main([]string{"-interactive", ctx.Test.Name()}, ctx.Stdin, ctx.Stdout, ctx.Stderr), 1; got != want {
+		t.Errorf("bad return code: got %d, want %d", got, want)
+	}
+
+	stdout := ctx.Stdout.String()
+	if !strings.HasPrefix(stdout, "!\n--- ") {
+		t.Errorf("bad stdout: %q", stdout)
+	}
+	if !strings.HasSuffix(stdout, "Accept this change? [yN] \n# Ran 1 test, 0 skipped, 1 failed.\n") {
+		t.Errorf("bad stdout: %q", stdout)
+	}
+
+	b, err := os.ReadFile(ctx.Test.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), strings.Replace(failTestData, "foobaz", "foobar", 1); got != want {
+		t.Errorf("bad merged test: got %q, want %q", got, want)
+	}
+	if _, err := os.Stat(ctx.Test.Name() + ".err"); !os.IsNotExist(err) {
+		t.Errorf("err file was not removed: %v", err)
+	}
+}
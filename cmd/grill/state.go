@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// stateName is the name of the file that records the outcome
+// of each suite, so that the ones that failed can be rerun.
+const stateName = ".grillstate"
+
+// stateError is the outcome of a suite that couldn't be run. Other
+// outcomes are the suite statuses, like "passed" or "failed".
+const stateError = "error"
+
+// state records the outcome of the last run of each suite. Suites are
+// identified by their paths relative to dir, the directory holding the
+// state file, so that they are matched whichever directory grill runs in.
+//
+// A nil state records nothing.
+type state struct {
+	dir      string
+	outcomes map[string]string
+}
+
+// loadState reads the state file in dir. The state is empty
+// if the file doesn't exist.
+func loadState(dir string) (*state, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &state{dir: abs, outcomes: make(map[string]string)}
+
+	path := filepath.Join(abs, stateName)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: malformed line", path, n)
+		}
+		s.outcomes[fields[1]] = fields[0]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// save writes the state file, one "outcome<TAB>suite" line per suite.
+//
+// The file is replaced atomically, so that grill runs sharing
+// it never see it partly written.
+func (s *state) save() (err error) {
+	if s == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(s.outcomes))
+	for name := range s.outcomes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	path := filepath.Join(s.dir, stateName)
+	f, err := ioutil.TempFile(s.dir, stateName+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	w := bufio.NewWriter(f)
+	for _, name := range names {
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", s.outcomes[name], name); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// key returns the name the suite at path is recorded under.
+func (s *state) key(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+	if rel, err := filepath.Rel(s.dir, abs); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return abs
+}
+
+// set records the outcome of the suite at path.
+func (s *state) set(path, outcome string) {
+	if s != nil {
+		s.outcomes[s.key(path)] = outcome
+	}
+}
+
+// failed returns true if the suite at path failed, was flaky, or couldn't be run.
+// Suites without a recorded outcome are assumed to have failed,
+// so that they aren't left out of a rerun.
+func (s *state) failed(path string) bool {
+	outcome, ok := s.outcomes[s.key(path)]
+	return !ok || outcome == "failed" || outcome == "flaky" || outcome == stateError
+}
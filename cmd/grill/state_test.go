@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStateSave(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := loadState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.set(filepath.Join(dir, "a.t"), "passed")
+	s.set(filepath.Join(dir, "sub", "b.t"), "failed")
+	for i := 0; i < 2; i++ {
+		if err := s.save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := loadState(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"a.t": "passed", "sub/b.t": "failed"}
+	if !reflect.DeepEqual(got.outcomes, want) {
+		t.Errorf("bad state: got %v, want %v", got.outcomes, want)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestStateNil(t *testing.T) {
+	var s *state
+	s.set("a.t", "passed")
+	if err := s.save(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStateFailed(t *testing.T) {
+	s := &state{dir: "/root", outcomes: map[string]string{
+		"a.t":     "passed",
+		"b.t":     "failed",
+		"c.t":     stateError,
+		"sub/e.t": "flaky",
+	}}
+	for path, want := range map[string]bool{
+		"/root/a.t":     false,
+		"/root/b.t":     true,
+		"/root/c.t":     true,
+		"/root/d.t":     true,
+		"/root/sub/e.t": true,
+	} {
+		if got := s.failed(path); got != want {
+			t.Errorf("failed(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
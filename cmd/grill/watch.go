@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// stamps identify the contents of files by their
+// modification times and sizes, indexed by path.
+type stamps map[string]string
+
+func fileStamp(info fs.FileInfo) string {
+	return fmt.Sprintf("%d:%d", info.ModTime().UnixNano(), info.Size())
+}
+
+// stampFiles returns the stamps of files at paths that exist.
+func stampFiles(paths []string) stamps {
+	s := make(stamps)
+	for _, p := range paths {
+		if info, err := os.Stat(p); err == nil {
+			s[p] = fileStamp(info)
+		}
+	}
+	return s
+}
+
+// stampDirs returns the stamps of all files within dirs, leaving out
+// hidden files as well as the files grill writes itself.
+func stampDirs(dirs []string) stamps {
+	s := make(stamps)
+	for _, dir := range dirs {
+		_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Files may come and go during the walk.
+				return nil
+			}
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() || filepath.Ext(path) == ".err" {
+				return nil
+			}
+			if info, err := d.Info(); err == nil {
+				s[path] = fileStamp(info)
+			}
+			return nil
+		})
+	}
+	return s
+}
+
+func (s stamps) equal(t stamps) bool {
+	if len(s) != len(t) {
+		return false
+	}
+	for p, stamp := range s {
+		if t[p] != stamp {
+			return false
+		}
+	}
+	return true
+}
+
+// watch runs the suites at paths and then keeps polling for changes,
+// running the suites selected by args again as they change. When anything
+// within the -watch-dir directories changes, all of the suites are run.
+// With -failed, only the suites that didn't pass last time are ever run.
+//
+// Each run happens in a fresh working directory and ends with a report.
+// Watching stops on interrupt, which kills the suites that are running.
+func (s *session) watch(args []string, paths []string) int {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+
+	cancel := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-interrupt:
+			close(cancel)
+		case <-done:
+		}
+	}()
+	s.context.Cancel = cancel
+
+	suiteStamps := stampFiles(paths)
+	dirStamps := stampDirs(opts.watchDirs.values)
+	paths = s.selectFailed(paths)
+
+	for cycle := 1; ; cycle++ {
+		if len(paths) > 0 {
+			s.runCycle(cycle, paths)
+		}
+
+		for {
+			select {
+			case <-cancel:
+				return 0
+			case <-time.After(*opts.watchPoll):
+			}
+
+			all, err := testPaths(args)
+			if err != nil {
+				log.Println(err)
+				continue
+			}
+
+			newSuiteStamps := stampFiles(all)
+			newDirStamps := stampDirs(opts.watchDirs.values)
+
+			if !newDirStamps.equal(dirStamps) {
+				paths = all
+			} else {
+				paths = nil
+				for _, p := range all {
+					if newSuiteStamps[p] != suiteStamps[p] {
+						paths = append(paths, p)
+					}
+				}
+			}
+
+			suiteStamps, dirStamps = newSuiteStamps, newDirStamps
+			paths = s.selectFailed(paths)
+			if len(paths) > 0 {
+				break
+			}
+		}
+	}
+}
+
+// runCycle runs the suites at paths in a working directory of their own.
+func (s *session) runCycle(cycle int, paths []string) {
+	context := s.context
+	context.WorkDir = filepath.Join(s.context.WorkDir, fmt.Sprintf("watch%d", cycle))
+	if err := os.Mkdir(context.WorkDir, 0700); err != nil {
+		log.Println(err)
+		return
+	}
+	if !*opts.keepTmpdir {
+		defer os.RemoveAll(context.WorkDir)
+	}
+
+	_ = s.runTests(context, paths)
+}
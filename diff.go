@@ -240,18 +240,65 @@ func CreateHunks(changes []*Change, aLen int, ctxLen int) []*Hunk {
 }
 
 // WriteDiff writes suite diff in the unified text format.
-func (suite *TestSuite) WriteDiff(w io.Writer, ctxLen int) error {
-	var expLines [][]byte
-	var obsLines [][]byte
+//
+// Commands and output lines are indented with indent spaces.
+func (suite *TestSuite) WriteDiff(w io.Writer, ctxLen int, indent int) error {
+	expLines, obsLines, testChanges := suite.diffLines(indent)
+
 	var changes []*Change
+	for _, c := range testChanges {
+		changes = append(changes, c...)
+	}
+
+	hunks := CreateHunks(changes, len(expLines), ctxLen)
+
+	if _, err := fmt.Fprint(w, "--- ", suite.Name, "\n"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(w, "+++ ", suite.Name, ".err", "\n"); err != nil {
+		return err
+	}
+
+	for _, h := range hunks {
+		if err := h.Write(w, expLines, obsLines); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeTestDiff writes the hunks of i-th test in the suite in the unified
+// text format. Line numbers and context refer to the whole suite.
+func (suite *TestSuite) writeTestDiff(w io.Writer, i int, ctxLen int, indent int) error {
+	expLines, obsLines, changes := suite.diffLines(indent)
+
+	for _, h := range CreateHunks(changes[i], len(expLines), ctxLen) {
+		if err := h.Write(w, expLines, obsLines); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffLines returns expected and observed lines of the whole suite, as they
+// appear in the test file, along with changes of each test converted to
+// absolute offsets within those lines.
+func (suite *TestSuite) diffLines(indent int) (expLines, obsLines [][]byte, changes [][]*Change) {
+	changes = make([][]*Change, len(suite.Tests))
+
+	for i, t := range suite.Tests {
+		pad := []byte(t.pad(indent))
+		cmdPrefix := concat(pad, []byte("$ "))
+		contPrefix := concat(pad, []byte("> "))
 
-	for _, t := range suite.Tests {
 		var cmdLines [][]byte
 		for i, line := range t.command {
 			if i == 0 {
-				cmdLines = append(cmdLines, append([]byte("  $ "), line...))
+				cmdLines = append(cmdLines, concat(cmdPrefix, line))
 			} else {
-				cmdLines = append(cmdLines, append([]byte("  > "), line...))
+				cmdLines = append(cmdLines, concat(contPrefix, line))
 			}
 		}
 
@@ -263,7 +310,7 @@ func (suite *TestSuite) WriteDiff(w io.Writer, ctxLen int) error {
 
 		for _, c := range t.changes {
 			// Convert to absolute offsets.
-			changes = append(changes, &Change{
+			changes[i] = append(changes[i], &Change{
 				A:   c.A + len(expLines),
 				B:   c.B + len(obsLines),
 				Del: c.Del,
@@ -272,29 +319,20 @@ func (suite *TestSuite) WriteDiff(w io.Writer, ctxLen int) error {
 		}
 
 		for _, line := range t.expResults {
-			expLines = append(expLines, append([]byte("  "), line...))
+			expLines = append(expLines, concat(pad, line))
 		}
 		for _, line := range t.obsResults {
-			obsLines = append(obsLines, append([]byte("  "), line...))
+			obsLines = append(obsLines, concat(pad, line))
 		}
 	}
 
-	hunks := CreateHunks(changes, len(expLines), ctxLen)
-
-	if _, err := fmt.Fprint(w, "--- ", suite.Name, "\n"); err != nil {
-		return err
-	}
-	if _, err := fmt.Fprint(w, "+++ ", suite.Name, ".err", "\n"); err != nil {
-		return err
-	}
-
-	for _, h := range hunks {
-		if err := h.Write(w, expLines, obsLines); err != nil {
-			return err
-		}
-	}
+	return expLines, obsLines, changes
+}
 
-	return nil
+// concat returns a new slice holding a followed by b.
+func concat(a, b []byte) []byte {
+	c := make([]byte, 0, len(a)+len(b))
+	return append(append(c, a...), b...)
 }
 
 func intMin(a, b int) int {
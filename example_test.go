@@ -0,0 +1,49 @@
+package grill_test
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/echlebek/grill"
+)
+
+func Example() {
+	const test = "Greet:\n\n  $ echo hello\n  goodbye\n"
+
+	suite := &grill.TestSuite{Name: "greet.t"}
+	r := grill.NewReader(strings.NewReader(test), 2)
+	for {
+		var t grill.Test
+		err := r.Read(&t)
+		if err != nil && err != io.EOF {
+			log.Fatal(err)
+		}
+		suite.Tests = append(suite.Tests, t)
+		if err == io.EOF {
+			break
+		}
+	}
+
+	ctx, err := grill.DefaultTestContext("/bin/sh", nil, false)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(ctx.WorkDir)
+
+	if err := suite.Run(ctx); err != nil {
+		log.Fatal(err)
+	}
+
+	for _, t := range suite.Tests {
+		if t.Failed() {
+			fmt.Printf("line %d: %s\n", t.CommandLine(), t.Command()[0])
+			fmt.Printf("expected %q, observed %q\n", t.Expected(), t.Observed())
+		}
+	}
+	// Output:
+	// line 3: echo hello
+	// expected ["goodbye"], observed ["hello"]
+}
@@ -50,6 +50,8 @@ Exit code:
 
   $ (exit 1)
   [1]
+  $ (exit 123)
+  [123]
 
 Write to stderr:
 
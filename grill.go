@@ -0,0 +1,901 @@
+// Package grill reads, runs and reports on cram-like test suites.
+//
+// Tests are read from a test file with a Reader, run as part of their
+// TestSuite with TestSuite.Run, and reported on with WriteReport,
+// WriteXUnit, a JSONWriter or WriteTAP.
+package grill
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Test is a single grill test. It is comprised of documentation, commands, and
+// expected test results.
+type Test struct {
+	line       int
+	block      int
+	doc        [][]byte
+	command    [][]byte
+	expResults [][]byte
+	// docLines, cmdLines and expLines hold the numbers of the lines
+	// of doc, command and expResults in the test file. Readers may
+	// leave lines out, so they don't necessarily follow each other.
+	docLines []int
+	cmdLines []int
+	expLines []int
+	// fence is the fence that opened the Markdown code block
+	// the test was read from, if it's a fenced one.
+	fence []byte
+	// eols holds the endings of the test's lines as they were read:
+	// "\n", "\r\n", or "" for a last line without one. It's nil
+	// if all of the lines end with "\n".
+	eols       []string
+	obsResults [][]byte
+	changes    []*Change
+	output     []byte
+	exitStatus int
+	duration   time.Duration
+	skipped    bool
+	filtered   bool
+	variants   []variant
+}
+
+// variant is one of several distinct outputs observed for a test
+// when its suite is run more than once.
+type variant struct {
+	lines [][]byte
+	// runs are the 1-based numbers of the runs that produced it.
+	runs []int
+}
+
+// Line returns the number of the line the test starts at in its file,
+// counting from 1, or 0 if the test wasn't read from a file.
+func (t *Test) Line() int {
+	return t.line
+}
+
+// CommandLine returns the number of the line the test's command starts at,
+// or 0 if the test has no command or wasn't read from a file.
+func (t *Test) CommandLine() int {
+	return lineAt(t.cmdLines, 0)
+}
+
+// DocLine returns the number of the line of the ith line of documentation,
+// or 0 if there is no such line or the test wasn't read from a file.
+func (t *Test) DocLine(i int) int {
+	return lineAt(t.docLines, i)
+}
+
+// ExpectedLine returns the number of the line of the ith expected output
+// line, or 0 if there is no such line or the test wasn't read from a file.
+func (t *Test) ExpectedLine(i int) int {
+	return lineAt(t.expLines, i)
+}
+
+func lineAt(lines []int, i int) int {
+	if i < 0 || i >= len(lines) {
+		return 0
+	}
+	return lines[i]
+}
+
+// Doc returns the lines of documentation that precede the test's command.
+func (t *Test) Doc() [][]byte {
+	return t.doc
+}
+
+// Command returns the lines of the test's command, without the prompts.
+func (t *Test) Command() [][]byte {
+	return t.command
+}
+
+// Expected returns the lines of the test's expected output,
+// without indentation, keywords included.
+func (t *Test) Expected() [][]byte {
+	return t.expResults
+}
+
+// Observed returns the lines of the output of the test's command from
+// the last run. A non-zero exit status is appended as a "[N]" line.
+func (t *Test) Observed() [][]byte {
+	return t.obsResults
+}
+
+// Changes returns the differences between the expected and observed
+// output, or nil if the output was as expected.
+func (t *Test) Changes() []*Change {
+	return t.changes
+}
+
+// ExitStatus returns the exit status of the test's command from the
+// last run, or -1 if the command ran out of time.
+func (t *Test) ExitStatus() int {
+	return t.exitStatus
+}
+
+// pad returns the indentation of the test's command and output lines,
+// which is indent spaces unless the test was read from a Markdown code block.
+func (t *Test) pad(indent int) string {
+	switch t.block {
+	case blockFenced:
+		return ""
+	case blockIndented:
+		return markdownIndent
+	default:
+		return strings.Repeat(" ", indent)
+	}
+}
+
+// Failed returns true if the test's output was not as expected.
+func (t *Test) Failed() bool {
+	return len(t.changes) > 0
+}
+
+// Skipped returns true if the test has no command, or its command didn't run.
+func (t *Test) Skipped() bool {
+	return len(t.command) == 0 || t.skipped
+}
+
+// Filtered returns true if the test's command runs, but its output
+// is not compared because the test was not selected.
+func (t *Test) Filtered() bool {
+	return t.filtered
+}
+
+// Flaky returns true if the test's output varied between runs.
+func (t *Test) Flaky() bool {
+	return len(t.variants) > 1
+}
+
+// failedSteadily returns true if the test's output was not as expected,
+// without varying between runs.
+func (t *Test) failedSteadily() bool {
+	return t.Failed() && !t.Flaky()
+}
+
+// Duration returns how long the test's command took to run.
+func (t *Test) Duration() time.Duration {
+	return t.duration
+}
+
+// Status returns a string that represents the suite's overall status.
+//
+// It is normally used by runner to report the run progress.
+//
+// A suite with tests that fail every time is reported as failed,
+// even if other tests of it are flaky.
+func (suite TestSuite) Status() string {
+	if suite.NotRun {
+		return "not run"
+	} else if suite.failedSteadily() {
+		return "failed"
+	} else if suite.Flaky() {
+		return "flaky"
+	} else if suite.Skipped() {
+		return "skipped"
+	} else {
+		return "passed"
+	}
+}
+
+// StatusGlyph returns a one character string that
+// represents the suite's overall status.
+//
+// It is normally used by runner to report the run progress.
+func (suite TestSuite) StatusGlyph() string {
+	if suite.failedSteadily() {
+		return "!"
+	} else if suite.Flaky() {
+		return "~"
+	} else if suite.Skipped() {
+		return "s"
+	} else {
+		return "."
+	}
+}
+
+// A TestSuite represents a single grill test file.
+type TestSuite struct {
+	Name  string
+	Dir   string
+	Tests []Test
+
+	// NotRun marks a suite that was left out of the run,
+	// e.g. because too many other suites failed.
+	NotRun bool
+}
+
+// Failed returns true if any test in the suite failed.
+func (suite TestSuite) Failed() bool {
+	for _, t := range suite.Tests {
+		if t.Failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// failedSteadily returns true if any test in the suite
+// failed without its output varying between runs.
+func (suite TestSuite) failedSteadily() bool {
+	for _, t := range suite.Tests {
+		if t.failedSteadily() {
+			return true
+		}
+	}
+	return false
+}
+
+// Flaky returns true if the output of any test in the suite
+// varied between runs.
+func (suite TestSuite) Flaky() bool {
+	for _, t := range suite.Tests {
+		if t.Flaky() {
+			return true
+		}
+	}
+	return false
+}
+
+// Skipped returns true if all of the tests in the suite were skipped.
+func (suite TestSuite) Skipped() bool {
+	for _, t := range suite.Tests {
+		if !t.Skipped() {
+			return false
+		}
+	}
+	return true
+}
+
+// Select marks the tests that don't match re as filtered.
+//
+// If the suite's name matches re, all of its tests are selected.
+// Otherwise, tests are selected if their doc matches re. Tests without
+// any doc of their own share the doc of the nearest preceding test.
+//
+// Select returns false if no test in the suite was selected.
+func (suite *TestSuite) Select(re *regexp.Regexp) bool {
+	if re.MatchString(suite.Name) {
+		return true
+	}
+
+	selected := false
+	var doc []byte
+	for i := range suite.Tests {
+		t := &suite.Tests[i]
+		if d := bytes.TrimSpace(bytes.Join(t.doc, []byte("\n"))); len(d) > 0 {
+			doc = d
+		}
+		if len(t.command) == 0 {
+			continue
+		}
+		if re.Match(doc) {
+			selected = true
+		} else {
+			t.filtered = true
+		}
+	}
+	return selected
+}
+
+// WriteErr writes test.t.err to the directory that test.t is in.
+//
+// Commands and output lines are indented with indent spaces.
+func (suite TestSuite) WriteErr(indent int) (err error) {
+	tErr := suite.Name + ".err"
+	f, err := os.Create(tErr)
+	if err != nil {
+		return fmt.Errorf("couldn't write %s: %s", tErr, err)
+	}
+	defer func() {
+		if fErr := f.Close(); fErr != nil && err == nil {
+			err = fmt.Errorf("couldn't write %s: %s", tErr, fErr)
+		}
+	}()
+	if err := suite.writeTests(f, indent, observedResults); err != nil {
+		return fmt.Errorf("couldn't write %s: %s", tErr, err)
+	}
+	return nil
+}
+
+// Merge rewrites the suite's test file so that it expects the observed output.
+//
+// Only the expected lines that didn't match are replaced, so that
+// lines with keywords like (re) or (glob) that did match are kept as is.
+// The file is replaced atomically. Commands and output lines are indented
+// with indent spaces.
+func (suite TestSuite) Merge(indent int) (err error) {
+	info, err := os.Stat(suite.Name)
+	if err != nil {
+		return fmt.Errorf("couldn't merge %s: %s", suite.Name, err)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(suite.Name), filepath.Base(suite.Name)+".merge")
+	if err != nil {
+		return fmt.Errorf("couldn't merge %s: %s", suite.Name, err)
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	if err := suite.writeTests(f, indent, mergedResults); err != nil {
+		f.Close()
+		return fmt.Errorf("couldn't merge %s: %s", suite.Name, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("couldn't merge %s: %s", suite.Name, err)
+	}
+	if err := os.Chmod(f.Name(), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("couldn't merge %s: %s", suite.Name, err)
+	}
+	if err := os.Rename(f.Name(), suite.Name); err != nil {
+		return fmt.Errorf("couldn't merge %s: %s", suite.Name, err)
+	}
+	return nil
+}
+
+// observedResults returns the observed output of a test, escaped as needed.
+func observedResults(t *Test) []string {
+	lines := make([]string, 0, len(t.obsResults))
+	for _, line := range t.obsResults {
+		lines = append(lines, escape(line))
+	}
+	return t.escapeBlock(lines)
+}
+
+// mergedResults returns the expected output of a test with
+// all of the changes from the observed output applied.
+func mergedResults(t *Test) []string {
+	var lines []string
+	a := 0
+	for _, c := range t.changes {
+		for _, line := range t.expResults[a:c.A] {
+			lines = append(lines, string(line))
+		}
+		for _, line := range t.obsResults[c.B : c.B+c.Ins] {
+			lines = append(lines, escape(line))
+		}
+		a = c.A + c.Del
+	}
+	for _, line := range t.expResults[a:] {
+		lines = append(lines, string(line))
+	}
+	return t.escapeBlock(lines)
+}
+
+// escapeBlock escapes the output lines of a test read from a Markdown code
+// block that would otherwise be read back as something else: lines starting
+// with a prompt, and in fenced blocks, lines closing the block as well as
+// blank lines at the end of the output, which only separate it from what
+// follows.
+func (t *Test) escapeBlock(lines []string) []string {
+	if t.block == blockNone {
+		return lines
+	}
+
+	end := len(lines)
+	if t.block == blockFenced {
+		for end > 0 && lines[end-1] == "" {
+			end--
+		}
+	}
+
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		switch {
+		case i >= end:
+			line = " (esc)"
+		case strings.HasPrefix(line, "$ "),
+			i == 0 && strings.HasPrefix(line, "> "),
+			t.block == blockFenced && closesFence([]byte(line), t.fence):
+			// Lines without the keyword have nothing else to escape.
+			line = fmt.Sprintf(`\x%02x`, line[0]) + line[1:]
+			if !strings.HasSuffix(line, " (esc)") {
+				line += " (esc)"
+			}
+		}
+		escaped[i] = line
+	}
+	return escaped
+}
+
+// expectedResults returns the expected output of a test as it was read.
+func expectedResults(t *Test) []string {
+	lines := make([]string, 0, len(t.expResults))
+	for _, line := range t.expResults {
+		lines = append(lines, string(line))
+	}
+	return lines
+}
+
+// writeTests writes the suite in the test file format, using results
+// to produce the output lines of each test.
+func (suite TestSuite) writeTests(w io.Writer, indent int, results func(*Test) []string) error {
+	tw := &testWriter{w: w, indent: indent, results: results}
+	for i := range suite.Tests {
+		if err := tw.Write(&suite.Tests[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Writer writes tests out in the test file format, one at a time.
+type Writer interface {
+	Write(*Test) error
+}
+
+type testWriter struct {
+	w       io.Writer
+	indent  int
+	results func(*Test) []string
+}
+
+// NewWriter creates a Writer of tests whose commands and output lines
+// are indented with indent spaces.
+//
+// Writing out all of the tests read by a Reader with the same indent
+// reproduces the input byte for byte, including its line endings.
+func NewWriter(w io.Writer, indent int) Writer {
+	return &testWriter{w: w, indent: indent, results: expectedResults}
+}
+
+func (tw *testWriter) Write(t *Test) error {
+	pad := t.pad(tw.indent)
+	var lines []string
+	for _, d := range t.doc {
+		lines = append(lines, string(d))
+	}
+	for i, line := range t.command {
+		prompt := "$ "
+		if i > 0 {
+			prompt = "> "
+		}
+		lines = append(lines, pad+prompt+string(line))
+	}
+	for _, line := range tw.results(t) {
+		lines = append(lines, pad+line)
+	}
+
+	for i, line := range lines {
+		if _, err := io.WriteString(tw.w, line+t.eol(i, len(lines))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eol returns the ending of the i-th of n lines written out for the test.
+//
+// The endings that were read are kept, so long as the lines they
+// belong to exist. A missing newline at the end of the file stays
+// at the end of the file.
+func (t *Test) eol(i, n int) string {
+	last := len(t.eols) - 1
+	if i == n-1 && last >= 0 && t.eols[last] == "" {
+		return ""
+	}
+	if i <= last && t.eols[i] != "" {
+		return t.eols[i]
+	}
+	if last >= 0 && t.eols[0] == "\r\n" {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// WriteVariants writes out the distinct outputs of the tests whose output
+// varied between runs, along with the numbers of the runs that produced them.
+//
+// Commands and output lines are indented with indent spaces.
+func (suite TestSuite) WriteVariants(w io.Writer, indent int) error {
+	if _, err := fmt.Fprintf(w, "--- %s (flaky)\n", suite.Name); err != nil {
+		return err
+	}
+	for i := range suite.Tests {
+		t := &suite.Tests[i]
+		if !t.Flaky() {
+			continue
+		}
+		if err := t.writeVariants(w, indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeVariants writes out the test's command followed by
+// each of its distinct outputs.
+func (t *Test) writeVariants(w io.Writer, indent int) error {
+	pad := t.pad(indent)
+	for i, line := range t.command {
+		prompt := "$"
+		if i > 0 {
+			prompt = ">"
+		}
+		if _, err := fmt.Fprintf(w, "%s%s %s\n", pad, prompt, line); err != nil {
+			return err
+		}
+	}
+	for _, v := range t.variants {
+		runs := make([]string, len(v.runs))
+		for i, r := range v.runs {
+			runs[i] = strconv.Itoa(r)
+		}
+		plural := "s"
+		if len(runs) == 1 {
+			plural = ""
+		}
+		if _, err := fmt.Fprintf(w, "run%s %s:\n", plural, strings.Join(runs, ", ")); err != nil {
+			return err
+		}
+		for _, line := range v.lines {
+			if _, err := fmt.Fprintf(w, "%s%s\n", pad, escape(line)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeLocations writes out the file and line of the command
+// of each test matching pred, followed by msg.
+func (suite TestSuite) writeLocations(w io.Writer, pred func(*Test) bool, msg string) error {
+	for i := range suite.Tests {
+		t := &suite.Tests[i]
+		if !pred(t) {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s:%d: %s\n", suite.Name, t.CommandLine(), msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveErr removes the matching .err file, if it exists.
+func (suite TestSuite) RemoveErr() error {
+	if err := os.Remove(suite.Name + ".err"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// ReportOptions control the contents of the report written by WriteReport.
+type ReportOptions struct {
+	// ContextLines is the number of diff context lines
+	// to leave around each change.
+	ContextLines int
+	// Indent is the number of spaces commands and
+	// output lines are indented with in diffs.
+	Indent int
+	// Quiet hides the suite diffs, leaving just the status summary.
+	Quiet bool
+	// Shard, if set, identifies the subset of suites that ran.
+	Shard string
+	// Shuffled tells that suites ran in random order, seeded with Seed.
+	Shuffled bool
+	Seed     int64
+}
+
+// WriteReport writes out a report on the overall grill run.
+func WriteReport(w io.Writer, suites []*TestSuite, opts ReportOptions) error {
+	tests, failed, skipped, filtered, notRun, flaky := 0, 0, 0, 0, 0, 0
+
+	for _, s := range suites {
+		if s.NotRun {
+			notRun++
+			continue
+		}
+		for _, t := range s.Tests {
+			if t.Filtered() {
+				filtered++
+			}
+		}
+		// A suite can both fail and be flaky, in which case
+		// it's counted, and its diff written out, as both.
+		flakes := s.Flaky()
+		if s.failedSteadily() {
+			failed++
+			if !opts.Quiet {
+				if err := s.writeLocations(w, (*Test).failedSteadily, "output changed"); err != nil {
+					return err
+				}
+				if err := s.WriteDiff(w, opts.ContextLines, opts.Indent); err != nil {
+					return fmt.Errorf("couldn't write %q: %s", s.Name+".err", err)
+				}
+			}
+		}
+		if flakes {
+			flaky++
+			if !opts.Quiet {
+				if err := s.writeLocations(w, (*Test).Flaky, "output varies between runs"); err != nil {
+					return err
+				}
+				if err := s.WriteVariants(w, opts.Indent); err != nil {
+					return err
+				}
+			}
+		} else if !s.Failed() && s.Skipped() {
+			skipped++
+		}
+		tests++
+	}
+
+	plural := "s"
+	if tests == 1 {
+		plural = ""
+	}
+
+	if opts.Shard != "" {
+		if _, err := fmt.Fprintf(w, "# Shard %s.\n", opts.Shard); err != nil {
+			return err
+		}
+	}
+
+	if opts.Shuffled {
+		if _, err := fmt.Fprintf(w, "# Shuffled with seed %d.\n", opts.Seed); err != nil {
+			return err
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "# Ran %d test%s, %d skipped, %d failed", tests, plural, skipped, failed); err != nil {
+		return err
+	}
+	if filtered > 0 {
+		if _, err := fmt.Fprintf(w, ", %d filtered", filtered); err != nil {
+			return err
+		}
+	}
+	if flaky > 0 {
+		if _, err := fmt.Fprintf(w, ", %d flaky", flaky); err != nil {
+			return err
+		}
+	}
+	if notRun > 0 {
+		if _, err := fmt.Fprintf(w, ", %d not run", notRun); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprint(w, ".\n")
+	return err
+}
+
+const (
+	stateDoc      = 0
+	stateCmdStart = 1
+	stateCmdCont  = 2
+	stateExp      = 3
+)
+
+// Reader reads tests from a test file, one at a time. Read returns io.EOF
+// along with the last test in the file.
+type Reader interface {
+	Read(*Test) error
+}
+
+type testReader struct {
+	scanner *lookaheadScanner
+	state   int
+	indent  int
+
+	// Line prefixes for output, commands and command continuations.
+	pad        []byte
+	cmdPrefix  []byte
+	contPrefix []byte
+}
+
+// NewReader creates a Reader of tests whose commands and
+// output lines are indented with indent spaces.
+func NewReader(r io.Reader, indent int) Reader {
+	pad := bytes.Repeat([]byte{' '}, indent)
+	return &testReader{
+		scanner:    newLookaheadScanner(r),
+		state:      stateDoc,
+		indent:     indent,
+		pad:        pad,
+		cmdPrefix:  concat(pad, []byte("$ ")),
+		contPrefix: concat(pad, []byte("> ")),
+	}
+}
+
+func synErr(line int, msg string) error {
+	return fmt.Errorf("syntax error parsing line %d: %s", line, msg)
+}
+
+// scanLines is like bufio.ScanLines, but it keeps the line endings
+// for lookaheadScanner to record.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func newLookaheadScanner(r io.Reader) *lookaheadScanner {
+	scanner := bufio.NewScanner(r)
+	// Lines are allowed to be arbitrarily long, e.g. minified JSON.
+	scanner.Buffer(nil, math.MaxInt)
+	scanner.Split(scanLines)
+	return &lookaheadScanner{Scanner: scanner}
+}
+
+type lookaheadScanner struct {
+	*bufio.Scanner
+	last   []byte
+	unread []byte
+	// line is the number of the last line returned by Bytes,
+	// and eol is the ending that was stripped from it.
+	line int
+	eol  string
+}
+
+func (l *lookaheadScanner) Scan() bool {
+	if l.unread != nil {
+		return true
+	}
+
+	return l.Scanner.Scan()
+}
+
+func (l *lookaheadScanner) Bytes() []byte {
+	l.line++
+	if l.unread != nil {
+		unread := l.unread
+		l.unread = nil
+		return unread
+	}
+	line := l.Scanner.Bytes()
+	switch {
+	case bytes.HasSuffix(line, []byte("\r\n")):
+		line, l.eol = line[:len(line)-2], "\r\n"
+	case bytes.HasSuffix(line, []byte("\n")):
+		line, l.eol = line[:len(line)-1], "\n"
+	default:
+		l.eol = ""
+	}
+	l.last = line
+	return l.last
+}
+
+func (l *lookaheadScanner) Unread() {
+	if l.unread != nil {
+		panic("double Unread()")
+	}
+	l.unread = l.last
+	l.line--
+}
+
+// unreadAfter pushes the last line read back, for the next test to start
+// with, and records the endings of the lines of test, which ends before it.
+func (l *lookaheadScanner) unreadAfter(test *Test, eols []string) {
+	l.Unread()
+	test.eols = lineEndings(eols[:len(eols)-1])
+}
+
+func (t *testReader) Read(test *Test) error {
+	*test = Test{}
+
+	var eols []string
+	for t.scanner.Scan() {
+		buf := t.scanner.Bytes()
+		line := make([]byte, len(buf))
+		copy(line, buf) // buf's data gets stomped next iteration
+		eols = append(eols, t.scanner.eol)
+		i := t.scanner.line
+		if test.line == 0 {
+			test.line = i
+		}
+		if len(line) == 0 {
+			if t.state == stateDoc {
+				test.doc = append(test.doc, line)
+				test.docLines = append(test.docLines, i)
+				continue
+			}
+			t.state = stateDoc
+			if len(test.command) > 0 {
+				t.scanner.unreadAfter(test, eols)
+				return nil
+			}
+			eols = eols[:len(eols)-1]
+			continue
+		}
+		for {
+			switch t.state {
+			case stateDoc:
+				if bytes.HasPrefix(line, t.pad) {
+					if bytes.HasPrefix(line, t.cmdPrefix) {
+						t.state = stateCmdStart
+						continue
+					}
+					if t.indent == 2 {
+						return synErr(i, "expected '$ ' after two spaces")
+					}
+					return synErr(i, fmt.Sprintf("expected '$ ' after %d spaces", t.indent))
+				}
+				test.doc = append(test.doc, line)
+				test.docLines = append(test.docLines, i)
+			case stateCmdStart:
+				if len(line) <= len(t.cmdPrefix) {
+					return synErr(i, "line too short")
+				}
+				// Assume next line is continuation; next state will
+				// unread and go straight to exp state if necessary.
+				t.state = stateCmdCont
+				test.command = append(test.command, line[len(t.cmdPrefix):])
+				test.cmdLines = append(test.cmdLines, i)
+			case stateCmdCont:
+				if !bytes.HasPrefix(line, t.contPrefix) {
+					t.state = stateExp
+					continue
+				}
+				test.command = append(test.command, line[len(t.contPrefix):])
+				test.cmdLines = append(test.cmdLines, i)
+			case stateExp:
+				if bytes.HasPrefix(line, t.cmdPrefix) {
+					t.state = stateCmdStart
+					t.scanner.unreadAfter(test, eols)
+					return nil
+				}
+				if bytes.HasPrefix(line, t.pad) {
+					test.expResults = append(test.expResults, line[t.indent:])
+					test.expLines = append(test.expLines, i)
+				} else {
+					t.state = stateDoc
+					t.scanner.unreadAfter(test, eols)
+					return nil
+				}
+			}
+			break
+		}
+	}
+	if err := t.scanner.Err(); err != nil {
+		return fmt.Errorf("couldn't read tests: %s", err)
+	}
+	test.eols = lineEndings(eols)
+	return io.EOF
+}
+
+// lineEndings returns eols, or nil if all of them are "\n".
+func lineEndings(eols []string) []string {
+	for _, eol := range eols {
+		if eol != "\n" {
+			return eols
+		}
+	}
+	return nil
+}
+
+// Escape unprintable characters in a string, if there are any.
+//
+// If any character was escaped, append (esc) keyword to the end of the output string.
+func escape(s []byte) string {
+	a := string(s)
+	b := strconv.Quote(a)
+	b = b[1 : len(b)-1]
+	if a != b {
+		b += " (esc)"
+	}
+	return b
+}
+
+func byteSlicesToString(slice [][]byte) string {
+	return string(bytes.Join(slice, []byte("\n")))
+}
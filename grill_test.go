@@ -0,0 +1,346 @@
+package grill
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+const test1 = `Run grill examples:
+
+  $ grill -q examples examples/fail.t
+  .s.!.s.
+  # Ran 7 tests, 2 skipped, 1 failed.
+  [1]
+  $ md5 examples/fail.t examples/fail.t.err
+  .*\b0f598c2b7b8ca5bcb8880e492ff6b452\b.* (re)
+  .*\b7a23dfa85773c77648f619ad0f9df554\b.* (re)
+  $ rm examples/fail.t.err`
+
+func makeSpecs() []spec {
+	return []spec{
+		{
+			doc:     "Run grill examples:\n",
+			command: "grill -q examples examples/fail.t",
+			results: ".s.!.s.\n# Ran 7 tests, 2 skipped, 1 failed.\n[1]",
+		},
+		{
+			command: "md5 examples/fail.t examples/fail.t.err",
+			results: ".*\\b0f598c2b7b8ca5bcb8880e492ff6b452\\b.* (re)\n.*\\b7a23dfa85773c77648f619ad0f9df554\\b.* (re)",
+		},
+		{
+			command: "rm examples/fail.t.err",
+		},
+	}
+}
+
+type spec struct {
+	doc     string
+	command string
+	results string
+}
+
+func TestReadTests(t *testing.T) {
+	t.Parallel()
+	specs := makeSpecs()
+	buf := new(bytes.Buffer)
+	if _, err := buf.Write([]byte(test1)); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewReader(bytes.NewReader(buf.Bytes()), 2)
+
+	var (
+		test Test
+		err  error
+	)
+
+	var tests []Test
+
+	for err != io.EOF && len(tests) < 4 {
+		err = r.Read(&test)
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		tests = append(tests, test)
+	}
+
+	if len(tests) != len(specs) {
+		t.Fatalf("wrong number of tests: got %d, want %d", len(tests), len(specs))
+	}
+
+	join := byteSlicesToString
+
+	for i, spec := range specs {
+		test := tests[i]
+		if got, want := join(test.doc), spec.doc; got != want {
+			t.Errorf("test %d: bad doc: got %q, want %q", i, got, want)
+		}
+		if got, want := join(test.command), spec.command; got != want {
+			t.Errorf("test %d: bad cmd: got %q, want %q", i, got, want)
+		}
+		if got, want := join(test.expResults), spec.results; got != want {
+			t.Errorf("test %d: bad expected results: got %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestReadTestsIndent(t *testing.T) {
+	t.Parallel()
+	const test4 = "Doc\n    $ echo foo\n    > bar\n    foo bar\n  not output\n"
+
+	r := NewReader(bytes.NewReader([]byte(test4)), 4)
+
+	var test Test
+	if err := r.Read(&test); err != nil {
+		t.Fatal(err)
+	}
+
+	join := byteSlicesToString
+	if got, want := join(test.doc), "Doc"; got != want {
+		t.Errorf("bad doc: got %q, want %q", got, want)
+	}
+	if got, want := join(test.command), "echo foo\nbar"; got != want {
+		t.Errorf("bad cmd: got %q, want %q", got, want)
+	}
+	if got, want := join(test.expResults), "foo bar"; got != want {
+		t.Errorf("bad expected results: got %q, want %q", got, want)
+	}
+
+	if err := r.Read(&test); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	if got, want := join(test.doc), "  not output"; got != want {
+		t.Errorf("bad doc: got %q, want %q", got, want)
+	}
+}
+
+func TestReadTestsLinePositions(t *testing.T) {
+	t.Parallel()
+	const test = "Doc\n\n\n  $ echo foo\n  > bar\n  foo\n  bar\n\n\nMore\n  $ true\n  x\n"
+
+	tests := readAll(t, NewReader(strings.NewReader(test), 2))
+	if len(tests) != 2 {
+		t.Fatalf("bad number of tests: got %d, want 2", len(tests))
+	}
+	checkLines(t, &tests[0], []int{1, 2, 3}, 4, []int{6, 7})
+	checkLines(t, &tests[1], []int{8, 9, 10}, 11, []int{12})
+}
+
+// readAll reads all of the tests from r.
+func readAll(t *testing.T, r Reader) []Test {
+	t.Helper()
+	var tests []Test
+	for {
+		var test Test
+		err := r.Read(&test)
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		tests = append(tests, test)
+		if err == io.EOF {
+			return tests
+		}
+	}
+}
+
+// checkLines checks the line numbers of the lines of test.
+func checkLines(t *testing.T, test *Test, doc []int, cmd int, exp []int) {
+	t.Helper()
+	for i, want := range doc {
+		if got := test.DocLine(i); got != want {
+			t.Errorf("bad line of doc line %d: got %d, want %d", i, got, want)
+		}
+	}
+	if got := test.DocLine(len(doc)); got != 0 {
+		t.Errorf("bad line past the doc: got %d, want 0", got)
+	}
+	if got := test.CommandLine(); got != cmd {
+		t.Errorf("bad command line: got %d, want %d", got, cmd)
+	}
+	for i, want := range exp {
+		if got := test.ExpectedLine(i); got != want {
+			t.Errorf("bad line of expected line %d: got %d, want %d", i, got, want)
+		}
+	}
+	if got := test.ExpectedLine(len(exp)); got != 0 {
+		t.Errorf("bad line past the expected output: got %d, want 0", got)
+	}
+}
+
+func TestReadTestsLines(t *testing.T) {
+	t.Parallel()
+	const test = "Doc\n\n  $ echo foo\n  > bar\n  foo bar\n\nMore doc\n  $ true\nTrailing doc\n"
+
+	r := NewReader(strings.NewReader(test), 2)
+
+	want := []struct{ line, cmdLine int }{{1, 3}, {6, 8}, {9, 0}}
+	for i, w := range want {
+		var test Test
+		err := r.Read(&test)
+		if i < len(want)-1 && err != nil {
+			t.Fatal(err)
+		}
+		if got := test.Line(); got != w.line {
+			t.Errorf("test %d: bad line: got %d, want %d", i, got, w.line)
+		}
+		if got := test.CommandLine(); got != w.cmdLine {
+			t.Errorf("test %d: bad command line: got %d, want %d", i, got, w.cmdLine)
+		}
+	}
+
+	r = NewReader(strings.NewReader("  $ true\n\nDoc\n  oops\n"), 2)
+	var test2 Test
+	if err := r.Read(&test2); err != nil {
+		t.Fatal(err)
+	}
+	err := r.Read(&test2)
+	if err == nil || err.Error() != "syntax error parsing line 4: expected '$ ' after two spaces" {
+		t.Errorf("bad syntax error: %v", err)
+	}
+
+	r = NewReader(strings.NewReader("    oops\n"), 4)
+	err = r.Read(&test2)
+	if err == nil || err.Error() != "syntax error parsing line 1: expected '$ ' after 4 spaces" {
+		t.Errorf("bad syntax error: %v", err)
+	}
+}
+
+func TestReadTestsLongLines(t *testing.T) {
+	t.Parallel()
+	long := strings.Repeat("x", 1<<20)
+	test := "  $ echo " + long + "\n  " + long + "\n"
+
+	r := NewReader(strings.NewReader(test), 2)
+
+	var got Test
+	if err := r.Read(&got); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+	if got, want := byteSlicesToString(got.command), "echo "+long; got != want {
+		t.Errorf("bad cmd: got %d bytes, want %d", len(got), len(want))
+	}
+	if got, want := byteSlicesToString(got.expResults), long; got != want {
+		t.Errorf("bad expected results: got %d bytes, want %d", len(got), len(want))
+	}
+}
+
+func TestReadTestsError(t *testing.T) {
+	t.Parallel()
+	r := NewReader(io.MultiReader(
+		strings.NewReader("  $ echo foo\n  foo\n"),
+		iotest.ErrReader(errors.New("disk on fire")),
+	), 2)
+
+	var test Test
+	err := r.Read(&test)
+	if err == nil || err == io.EOF {
+		t.Fatalf("expected read error, got %v", err)
+	}
+	if got, want := err.Error(), "couldn't read tests: disk on fire"; got != want {
+		t.Errorf("bad error: got %q, want %q", got, want)
+	}
+}
+
+func TestWriteTests(t *testing.T) {
+	t.Parallel()
+	inputs := []string{
+		"",
+		"Doc only",
+		"\n\nDoc\n\n  $ echo foo\n  > bar\n  foo bar\n\n\n  $ true\nTrailing doc\n",
+		"  $ printf foo\n  foo (no-eol)\n  $ echo \\t\n  \\t (esc)\n  * (glob)",
+		"Doc\r\n  $ echo foo\r\n  foo\r\n\r\n  $ true",
+		"Mixed\r\n  $ echo foo\n  foo\r\n",
+	}
+
+	for _, input := range inputs {
+		r := NewReader(strings.NewReader(input), 2)
+		buf := new(bytes.Buffer)
+		w := NewWriter(buf, 2)
+
+		for {
+			var test Test
+			err := r.Read(&test)
+			if err != nil && err != io.EOF {
+				t.Fatal(err)
+			}
+			if err := w.Write(&test); err != nil {
+				t.Fatal(err)
+			}
+			if err == io.EOF {
+				break
+			}
+		}
+
+		if got := buf.String(); got != input {
+			t.Errorf("bad round trip: got %q, want %q", got, input)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	const data = `Scenario one:
+
+  $ echo a
+  a
+  $ echo b
+  b
+
+Scenario two:
+
+  $ echo c
+  c
+`
+
+	read := func() *TestSuite {
+		suite := &TestSuite{Name: "dir/suite.t"}
+		r := NewReader(bytes.NewReader([]byte(data)), 2)
+		var err error
+		for err == nil {
+			var test Test
+			err = r.Read(&test)
+			suite.Tests = append(suite.Tests, test)
+		}
+		if err != io.EOF {
+			t.Fatal(err)
+		}
+		return suite
+	}
+
+	filtered := func(suite *TestSuite) []bool {
+		var f []bool
+		for _, test := range suite.Tests {
+			if len(test.command) > 0 {
+				f = append(f, test.Filtered())
+			}
+		}
+		return f
+	}
+
+	tests := []struct {
+		re       string
+		selected bool
+		filtered []bool
+	}{
+		{re: "suite", selected: true, filtered: []bool{false, false, false}},
+		{re: "one", selected: true, filtered: []bool{false, false, true}},
+		{re: "two", selected: true, filtered: []bool{true, true, false}},
+		{re: "three", selected: false, filtered: []bool{true, true, true}},
+	}
+
+	for _, test := range tests {
+		suite := read()
+		if got, want := suite.Select(regexp.MustCompile(test.re)), test.selected; got != want {
+			t.Errorf("%q: bad selection: got %v, want %v", test.re, got, want)
+		}
+		if got, want := filtered(suite), test.filtered; !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: bad filtered tests: got %v, want %v", test.re, got, want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package grill
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event is a single entry of the JSON event stream written by JSONWriter.
+//
+// A shuffled run begins with a "shuffle" event holding the seed.
+// Actions of a suite are "start", followed by the events of its commands,
+// and finally "pass", "fail" or "skip". A suite that was left out of the
+// run ends with "notrun" instead. Actions of a command are "run", zero or
+// more "output", and finally "pass", "fail" or "skip". Commands that never
+// ran only get the "skip".
+type Event struct {
+	Time    time.Time
+	Action  string
+	Suite   string  `json:",omitempty"`
+	Command string  `json:",omitempty"`
+	Line    int     `json:",omitempty"` // of the command in the suite's file
+	Output  string  `json:",omitempty"`
+	Elapsed float64 `json:",omitempty"` // seconds
+	// Exit is the exit status of a command that ran to completion.
+	Exit *int  `json:",omitempty"`
+	Seed int64 `json:",omitempty"` // of a shuffled run
+}
+
+// JSONWriter writes out a stream of JSON encoded events, one per line.
+// It is safe for concurrent use.
+//
+// The events of a suite's commands are written out as they happen, by
+// Run and Done, which fit TestContext.CommandStarted and CommandFinished.
+type JSONWriter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+
+	// done holds the tests whose commands have been reported as done.
+	done map[*Test]bool
+}
+
+// NewJSONWriter creates a JSONWriter that writes to w.
+func NewJSONWriter(w io.Writer) *JSONWriter {
+	return &JSONWriter{enc: json.NewEncoder(w), done: make(map[*Test]bool)}
+}
+
+func (j *JSONWriter) emit(events ...Event) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, e := range events {
+		if err := j.enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shuffle writes out the event of suites being run in random order,
+// seeded with seed.
+func (j *JSONWriter) Shuffle(seed int64) error {
+	return j.emit(Event{Time: time.Now(), Action: "shuffle", Seed: seed})
+}
+
+// Start writes out the event of the suite starting to run.
+func (j *JSONWriter) Start(suite *TestSuite) error {
+	return j.emit(Event{Time: time.Now(), Action: "start", Suite: suite.Name})
+}
+
+// Run writes out the event of the test's command starting to run at at.
+func (j *JSONWriter) Run(suite *TestSuite, t *Test, at time.Time) error {
+	return j.emit(Event{
+		Time:    at,
+		Action:  "run",
+		Suite:   suite.Name,
+		Command: string(bytes.Join(t.command, []byte("\n"))),
+		Line:    t.CommandLine(),
+	})
+}
+
+// Done writes out the output of the test's command, which finished at at,
+// followed by the event of the command ending.
+func (j *JSONWriter) Done(suite *TestSuite, t *Test, at time.Time) error {
+	j.mu.Lock()
+	j.done[t] = true
+	j.mu.Unlock()
+	return j.emit(commandEvents(suite, t, at)...)
+}
+
+// commandEvents returns the events of the test's command ending at at.
+func commandEvents(suite *TestSuite, t *Test, at time.Time) []Event {
+	command := string(bytes.Join(t.command, []byte("\n")))
+	line := t.CommandLine()
+
+	var events []Event
+	end := Event{Time: at, Suite: suite.Name, Command: command, Line: line}
+	switch {
+	case t.Skipped():
+		end.Action = "skip"
+	case t.Filtered():
+		end.Action = "skip"
+	case t.Failed(), t.Flaky():
+		end.Action = "fail"
+	default:
+		end.Action = "pass"
+	}
+
+	if !t.skipped {
+		for _, out := range bytes.SplitAfter(t.output, []byte("\n")) {
+			if len(out) == 0 {
+				continue
+			}
+			events = append(events, Event{Time: at, Action: "output", Suite: suite.Name, Command: command, Line: line, Output: string(out)})
+		}
+		end.Elapsed = t.duration.Seconds()
+		if t.exitStatus >= 0 {
+			status := t.exitStatus
+			end.Exit = &status
+		}
+	}
+
+	return append(events, end)
+}
+
+// Write writes out the event of the suite ending, once it has run. It is
+// preceded by the events of the commands that weren't reported as done by
+// then, because they never ran or the suite was skipped. A suite that was
+// left out of the run only gets a "notrun" event.
+func (j *JSONWriter) Write(suite *TestSuite) error {
+	now := time.Now()
+	if suite.NotRun {
+		return j.emit(Event{Time: now, Action: "notrun", Suite: suite.Name})
+	}
+
+	var (
+		events []Event
+		total  time.Duration
+	)
+	j.mu.Lock()
+	for i := range suite.Tests {
+		t := &suite.Tests[i]
+		if len(t.command) == 0 {
+			continue
+		}
+		total += t.duration
+		if !j.done[t] {
+			events = append(events, commandEvents(suite, t, now)...)
+		}
+		delete(j.done, t)
+	}
+	j.mu.Unlock()
+
+	end := Event{Time: now, Action: "pass", Suite: suite.Name, Elapsed: total.Seconds()}
+	if suite.Flaky() || suite.Failed() {
+		end.Action = "fail"
+	} else if suite.Skipped() {
+		end.Action = "skip"
+	}
+
+	return j.emit(append(events, end)...)
+}
@@ -0,0 +1,126 @@
+package grill
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWriteJSON(t *testing.T) {
+	exp := [][]byte{[]byte("foo")}
+	obs := [][]byte{[]byte("bar"), []byte("[1]")}
+
+	started := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	first := started.Add(1500 * time.Millisecond)
+	second := first.Add(time.Millisecond)
+
+	suite := &TestSuite{
+		Name: "a.t",
+		Tests: []Test{
+			{
+				doc:        [][]byte{[]byte("Doc")},
+				command:    [][]byte{[]byte("echo foo"), []byte("echo <bar>")},
+				expResults: exp,
+				obsResults: exp,
+				output:     []byte("foo\n"),
+				duration:   1500 * time.Millisecond,
+			},
+			{
+				command:    [][]byte{[]byte("echo bar; false")},
+				expResults: exp,
+				obsResults: obs,
+				changes:    Diff(exp, obs),
+				output:     []byte("bar\n"),
+				exitStatus: 1,
+				duration:   time.Millisecond,
+			},
+			{
+				command: [][]byte{[]byte("true")},
+				skipped: true,
+			},
+			{
+				doc: [][]byte{[]byte("Trailing doc")},
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	w := NewJSONWriter(buf)
+	for _, step := range []func() error{
+		func() error { return w.Run(suite, &suite.Tests[0], started) },
+		func() error { return w.Done(suite, &suite.Tests[0], first) },
+		func() error { return w.Run(suite, &suite.Tests[1], first) },
+		func() error { return w.Done(suite, &suite.Tests[1], second) },
+		func() error { return w.Write(suite) },
+	} {
+		if err := step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	zero, one := 0, 1
+	want := []Event{
+		{Time: started, Action: "run", Suite: "a.t", Command: "echo foo\necho <bar>"},
+		{Time: first, Action: "output", Suite: "a.t", Command: "echo foo\necho <bar>", Output: "foo\n"},
+		{Time: first, Action: "pass", Suite: "a.t", Command: "echo foo\necho <bar>", Elapsed: 1.5, Exit: &zero},
+		{Time: first, Action: "run", Suite: "a.t", Command: "echo bar; false"},
+		{Time: second, Action: "output", Suite: "a.t", Command: "echo bar; false", Output: "bar\n"},
+		{Time: second, Action: "fail", Suite: "a.t", Command: "echo bar; false", Elapsed: 0.001, Exit: &one},
+		{Action: "skip", Suite: "a.t", Command: "true"},
+		{Action: "fail", Suite: "a.t", Elapsed: 1.501},
+	}
+
+	var got []Event
+	dec := json.NewDecoder(buf)
+	for dec.More() {
+		var e Event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		if len(got) >= 6 {
+			// Written once the suite has run.
+			if e.Time.IsZero() {
+				t.Errorf("event without time: %+v", e)
+			}
+			e.Time = time.Time{}
+		}
+		e.Time = e.Time.UTC()
+		got = append(got, e)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("bad events: got\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestJSONWriterNotRun(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := NewJSONWriter(buf).Write(&TestSuite{Name: "a.t", NotRun: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var e Event
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Action != "notrun" || e.Suite != "a.t" || e.Time.IsZero() {
+		t.Errorf("bad event: %+v", e)
+	}
+}
+
+func TestJSONWriterShuffle(t *testing.T) {
+	buf := new(bytes.Buffer)
+	if err := NewJSONWriter(buf).Shuffle(-42); err != nil {
+		t.Fatal(err)
+	}
+
+	var e Event
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Action != "shuffle" || e.Seed != -42 || e.Suite != "" {
+		t.Errorf("bad event: %+v", e)
+	}
+}
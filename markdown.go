@@ -0,0 +1,218 @@
+package grill
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Kinds of Markdown code blocks that tests are read from.
+const (
+	blockNone     = 0
+	blockIndented = 1
+	blockFenced   = 2
+)
+
+// markdownIndent is the indentation of Markdown indented code blocks.
+const markdownIndent = "    "
+
+type markdownReader struct {
+	scanner *lookaheadScanner
+
+	// pending holds the blank lines that trailed the output of the last
+	// test in a fenced code block. They start the next test's doc.
+	pending []markdownLine
+
+	// fence is the opening fence of the fenced code block being read,
+	// if any, and console tells whether the block holds a shell session.
+	fence   []byte
+	console bool
+
+	// paragraph tells whether the last line of documentation belongs
+	// to a paragraph, which an indented code block can't interrupt.
+	paragraph bool
+}
+
+// NewMarkdownReader creates a Reader of tests embedded in a Markdown document.
+//
+// Commands are lines starting with a "$ " prompt, either in an indented
+// code block or in a code block fenced with ```console. Lines starting
+// with "> " continue a command, and the lines up to the next command or
+// the end of the block are its expected output. Everything else in the
+// document, including other code blocks, is documentation.
+func NewMarkdownReader(r io.Reader) Reader {
+	return &markdownReader{scanner: newLookaheadScanner(r)}
+}
+
+// markdownLine is a line of a Markdown document, along with its
+// ending and number.
+type markdownLine struct {
+	text []byte
+	eol  string
+	n    int
+}
+
+// next returns the next line to be read.
+func (m *markdownReader) next() (markdownLine, bool) {
+	if len(m.pending) > 0 {
+		l := m.pending[0]
+		m.pending = m.pending[1:]
+		return l, true
+	}
+	if !m.scanner.Scan() {
+		return markdownLine{}, false
+	}
+	buf := m.scanner.Bytes()
+	line := make([]byte, len(buf))
+	copy(line, buf) // buf's data gets stomped next iteration
+	return markdownLine{text: line, eol: m.scanner.eol, n: m.scanner.line}, true
+}
+
+func (m *markdownReader) Read(test *Test) error {
+	*test = Test{}
+
+	var (
+		eols   []string
+		blanks []markdownLine
+	)
+	for {
+		l, ok := m.next()
+		if !ok {
+			break
+		}
+		line := l.text
+		eols = append(eols, l.eol)
+		if test.line == 0 {
+			test.line = l.n
+		}
+
+		if len(test.command) == 0 {
+			m.readDoc(test, line, l.n)
+			continue
+		}
+
+		pad := []byte(test.pad(0))
+		if test.block == blockFenced && closesFence(line, m.fence) ||
+			isPrompt(line, pad, "$ ") {
+			// Blank lines between the output and what follows
+			// it only separate the two.
+			test.expResults = test.expResults[:len(test.expResults)-len(blanks)]
+			test.expLines = test.expLines[:len(test.expLines)-len(blanks)]
+			eols = eols[:len(eols)-len(blanks)]
+			m.pending = blanks
+			m.scanner.unreadAfter(test, eols)
+			return nil
+		}
+		if len(line) == 0 {
+			blanks = append(blanks, l)
+		} else {
+			blanks = nil
+		}
+		if len(test.expResults) == 0 && isPrompt(line, pad, "> ") {
+			test.command = append(test.command, line[len(pad)+2:])
+			test.cmdLines = append(test.cmdLines, l.n)
+			continue
+		}
+		if test.block == blockIndented && !bytes.HasPrefix(line, pad) {
+			m.scanner.unreadAfter(test, eols)
+			return nil
+		}
+		test.expResults = append(test.expResults, line[len(pad):])
+		test.expLines = append(test.expLines, l.n)
+	}
+	if err := m.scanner.Err(); err != nil {
+		return fmt.Errorf("couldn't read tests: %s", err)
+	}
+	test.eols = lineEndings(eols)
+	return io.EOF
+}
+
+// readDoc adds line, numbered n, to a test that has no command yet,
+// either as documentation or as the start of the command.
+func (m *markdownReader) readDoc(test *Test, line []byte, n int) {
+	switch {
+	case m.fence != nil:
+		if closesFence(line, m.fence) {
+			m.fence = nil
+		} else if m.console && isPrompt(line, nil, "$ ") {
+			test.block = blockFenced
+			test.fence = m.fence
+			test.command = append(test.command, line[2:])
+			test.cmdLines = append(test.cmdLines, n)
+			return
+		}
+	case !m.paragraph && isPrompt(line, []byte(markdownIndent), "$ "):
+		test.block = blockIndented
+		test.command = append(test.command, line[len(markdownIndent)+2:])
+		test.cmdLines = append(test.cmdLines, n)
+		return
+	default:
+		if fence, info := openFence(line); fence != nil {
+			m.fence = fence
+			m.console = string(info) == "console"
+			m.paragraph = false
+		} else {
+			// Indented lines continue a paragraph but don't start one.
+			blank := len(bytes.TrimSpace(line)) == 0
+			indented := bytes.HasPrefix(line, []byte(markdownIndent))
+			m.paragraph = !blank && !isHeading(line) && (m.paragraph || !indented)
+		}
+	}
+	test.doc = append(test.doc, line)
+	test.docLines = append(test.docLines, n)
+}
+
+// isPrompt returns true if line is pad followed by prompt and some text.
+func isPrompt(line, pad []byte, prompt string) bool {
+	return len(line) > len(pad)+len(prompt) &&
+		bytes.HasPrefix(line, pad) &&
+		bytes.HasPrefix(line[len(pad):], []byte(prompt))
+}
+
+// isHeading returns true if line is an ATX heading.
+func isHeading(line []byte) bool {
+	line = trimFenceIndent(line)
+	n := 0
+	for n < len(line) && line[n] == '#' {
+		n++
+	}
+	return n >= 1 && n <= 6 && (n == len(line) || line[n] == ' ' || line[n] == '\t')
+}
+
+// openFence returns the fence opening a fenced code block
+// and the block's info string, if line opens one.
+func openFence(line []byte) (fence, info []byte) {
+	line = trimFenceIndent(line)
+	for _, c := range []byte("`~") {
+		n := 0
+		for n < len(line) && line[n] == c {
+			n++
+		}
+		if n >= 3 {
+			return line[:n], bytes.TrimSpace(line[n:])
+		}
+	}
+	return nil, nil
+}
+
+// closesFence returns true if line closes the block opened with fence.
+func closesFence(line, fence []byte) bool {
+	line = bytes.TrimRight(trimFenceIndent(line), " \t")
+	if len(line) < len(fence) {
+		return false
+	}
+	for _, c := range line {
+		if c != fence[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// trimFenceIndent strips the up to 3 spaces that fences may be indented with.
+func trimFenceIndent(line []byte) []byte {
+	for i := 0; i < 3 && len(line) > 0 && line[0] == ' '; i++ {
+		line = line[1:]
+	}
+	return line
+}
@@ -0,0 +1,193 @@
+package grill
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+const markdownDoc = "# Usage\n" +
+	"\n" +
+	"    $ echo foo\n" +
+	"    foo\n" +
+	"\n" +
+	"    not a command\n" +
+	"\n" +
+	"```console\n" +
+	"$ echo bar\n" +
+	"> echo baz\n" +
+	"bar\n" +
+	"\n" +
+	"baz\n" +
+	"\n" +
+	"$ true\n" +
+	"```\n" +
+	"\n" +
+	"```sh\n" +
+	"$ echo ignored\n" +
+	"    $ echo ignored\n" +
+	"```\n" +
+	"The end.\n"
+
+func readMarkdown(t *testing.T, doc string) []Test {
+	t.Helper()
+	r := NewMarkdownReader(strings.NewReader(doc))
+	var tests []Test
+	for {
+		var test Test
+		err := r.Read(&test)
+		if err != nil && err != io.EOF {
+			t.Fatal(err)
+		}
+		tests = append(tests, test)
+		if err == io.EOF {
+			return tests
+		}
+	}
+}
+
+func TestReadMarkdown(t *testing.T) {
+	t.Parallel()
+	tests := readMarkdown(t, markdownDoc)
+
+	want := []struct {
+		doc, command, exp string
+		cmdLine           int
+	}{
+		{"# Usage\n", "echo foo", "foo", 3},
+		{"\n    not a command\n\n```console", "echo bar\necho baz", "bar\n\nbaz", 9},
+		{"", "true", "", 15},
+		{"```\n\n```sh\n$ echo ignored\n    $ echo ignored\n```\nThe end.", "", "", 0},
+	}
+	if len(tests) != len(want) {
+		t.Fatalf("bad number of tests: got %d, want %d", len(tests), len(want))
+	}
+
+	join := byteSlicesToString
+	for i, w := range want {
+		test := &tests[i]
+		if got := join(test.doc); got != w.doc {
+			t.Errorf("test %d: bad doc: got %q, want %q", i, got, w.doc)
+		}
+		if got := join(test.command); got != w.command {
+			t.Errorf("test %d: bad cmd: got %q, want %q", i, got, w.command)
+		}
+		if got := join(test.expResults); got != w.exp {
+			t.Errorf("test %d: bad expected results: got %q, want %q", i, got, w.exp)
+		}
+		if got := test.CommandLine(); got != w.cmdLine {
+			t.Errorf("test %d: bad command line: got %d, want %d", i, got, w.cmdLine)
+		}
+	}
+}
+
+func TestReadMarkdownLazyContinuation(t *testing.T) {
+	t.Parallel()
+	tests := readMarkdown(t, "Run it with\n"+
+		"    $ echo lazy\n"+
+		"\n"+
+		"## Heading\n"+
+		"    $ echo foo\n"+
+		"    foo\n")
+
+	if len(tests) != 1 {
+		t.Fatalf("bad number of tests: got %d, want 1", len(tests))
+	}
+	if got, want := byteSlicesToString(tests[0].doc), "Run it with\n    $ echo lazy\n\n## Heading"; got != want {
+		t.Errorf("bad doc: got %q, want %q", got, want)
+	}
+	if got, want := byteSlicesToString(tests[0].command), "echo foo"; got != want {
+		t.Errorf("bad cmd: got %q, want %q", got, want)
+	}
+}
+
+func TestReadMarkdownLinePositions(t *testing.T) {
+	t.Parallel()
+	tests := readMarkdown(t, "# T\n"+
+		"\n"+
+		"```console\n"+
+		"$ echo a\n"+
+		"a\n"+
+		"\n"+
+		"b\n"+
+		"\n"+
+		"\n"+
+		"$ true\n"+
+		"```\n")
+
+	if len(tests) != 3 {
+		t.Fatalf("bad number of tests: got %d, want 3", len(tests))
+	}
+	checkLines(t, &tests[0], []int{1, 2, 3}, 4, []int{5, 6, 7})
+	checkLines(t, &tests[1], []int{8, 9}, 10, nil)
+	checkLines(t, &tests[2], []int{11}, 0, nil)
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	t.Parallel()
+	buf := new(bytes.Buffer)
+	w := NewWriter(buf, 2)
+	for _, test := range readMarkdown(t, markdownDoc) {
+		if err := w.Write(&test); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := buf.String(); got != markdownDoc {
+		t.Errorf("bad round trip: got\n%s\nwant\n%s", got, markdownDoc)
+	}
+}
+
+func TestWriteMarkdownEscapes(t *testing.T) {
+	t.Parallel()
+	doc := "```console\n" +
+		"$ cmd\n" +
+		"```\n" +
+		"\n" +
+		"    $ cmd\n" +
+		"    old\n" +
+		"\n" +
+		"```console\n" +
+		"$ cmd\n" +
+		"old\n" +
+		"$ true\n" +
+		"```\n"
+	obs := [][]byte{
+		[]byte("a"),
+		[]byte("$ echo"),
+		[]byte("```"),
+		[]byte(""),
+		[]byte("b"),
+		[]byte(""),
+		[]byte(""),
+	}
+	tests := []struct {
+		name    string
+		results func(*Test) []string
+	}{
+		{"observed", observedResults},
+		{"merged", mergedResults},
+	}
+	for _, test := range tests {
+		suite := TestSuite{Tests: readMarkdown(t, doc)}
+		for i := range suite.Tests[:3] {
+			tt := &suite.Tests[i]
+			tt.obsResults = obs
+			tt.changes = Diff(tt.expResults, obs)
+		}
+
+		buf := new(bytes.Buffer)
+		if err := suite.writeTests(buf, 2, test.results); err != nil {
+			t.Fatal(err)
+		}
+		got := readMarkdown(t, buf.String())
+		if len(got) != len(suite.Tests) {
+			t.Fatalf("%s: got %d tests, want %d:\n%s", test.name, len(got), len(suite.Tests), buf)
+		}
+		for i := range got[:3] {
+			if c := Diff(got[i].expResults, obs); len(c) > 0 {
+				t.Errorf("%s: test %d doesn't expect its output:\n%s", test.name, i, buf)
+			}
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package grill
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op; process groups are not supported on Windows.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup kills cmd. Its children are left running.
+func killProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,581 @@
+package grill
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TestContext specifies an execution environment for running a test.
+type TestContext struct {
+	Environ []string
+	WorkDir string
+	Shell   []string
+
+	// Debug runs the tests with their output written directly
+	// to Stdout and Stderr. The output is not compared and the
+	// tests are marked as skipped.
+	Debug  bool
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Timeout and CommandTimeout limit how long a suite and each of
+	// its commands may run. Once either runs out, the shell's whole
+	// process group is killed. Zero means no limit.
+	Timeout        time.Duration
+	CommandTimeout time.Duration
+
+	// Closing Cancel kills the shell's process group of every suite
+	// that is running, which then ends as if it had run out of time.
+	Cancel <-chan struct{}
+
+	// CommandStarted and CommandFinished, if set, are called as each
+	// command of a suite is seen to start and finish running, along with
+	// when that was. The results of the command have been recorded in
+	// its test by the time CommandFinished is called. They are called
+	// from a goroutine of the runner, and not at all in Debug mode.
+	CommandStarted  func(suite *TestSuite, t *Test, at time.Time)
+	CommandFinished func(suite *TestSuite, t *Test, at time.Time)
+}
+
+// Default environment variables set by grill.
+const DefaultEnvironment = `LANG=C
+LC_ALL=C
+LANGUAGE=C
+TZ=GMT
+COLUMNS=80
+CDPATH=
+GREP_OPTIONS=`
+
+// DefaultTestContext creates a new TestContext with environment defaults.
+//
+// The function is meant to be called once per grill command invocation and
+// creates two things:
+//
+//  - An overall working directory root in default TMPDIR
+//  - A single local {workdir}/tmp temporary directory for all of the executed tests
+//
+// As tests execute later on, they will create named sub-directories
+// that will serve as their individual working directories.
+//
+// Tests are run by shell, which is invoked with shellOpts as its arguments.
+func DefaultTestContext(shell string, shellOpts []string, preserveEnv bool) (TestContext, error) {
+	wd, err := ioutil.TempDir("", "grilltests")
+	td := filepath.Join(wd, "tmp")
+	if err := os.Mkdir(td, 0700); err != nil {
+		return TestContext{}, err
+	}
+
+	var env []string
+	env = append(env, os.Environ()...)
+	env = append(env, []string{
+		fmt.Sprintf("TMPDIR=%s", td),
+		fmt.Sprintf("TEMP=%s", td),
+		fmt.Sprintf("TMP=%s", td),
+		fmt.Sprintf("GRILLTMP=%s", td),
+		fmt.Sprintf("CRAMTMP=%s", td),
+		fmt.Sprintf("TESTSHELL=%s", shell),
+	}...)
+	if !preserveEnv {
+		env = append(env, strings.Split(DefaultEnvironment, "\n")...)
+	}
+
+	return TestContext{
+		Shell:   append([]string{shell}, shellOpts...),
+		WorkDir: wd,
+		Environ: env,
+	}, err
+}
+
+// Cleanup removes the working directory of the test.
+func (t TestContext) Cleanup() error {
+	return os.RemoveAll(t.WorkDir)
+}
+
+// skipStatus is the exit status of a command that skips the suite.
+const skipStatus = 80
+
+// progressPollInterval is how often to check whether a command has finished.
+// It bounds how precisely commands are timed.
+const progressPollInterval = 5 * time.Millisecond
+
+// skip marks all of the tests in the suite as skipped,
+// dropping the results of any commands that already ran.
+func (suite *TestSuite) skip() {
+	for i := range suite.Tests {
+		t := &suite.Tests[i]
+		t.obsResults, t.changes, t.output = nil, nil, nil
+		t.exitStatus, t.duration, t.skipped = 0, 0, true
+	}
+}
+
+// commandStarted calls ctx.CommandStarted for tests that have a command.
+func (ctx TestContext) commandStarted(suite *TestSuite, t *Test, at time.Time) {
+	if ctx.CommandStarted != nil && !ctx.Debug && len(t.command) > 0 {
+		ctx.CommandStarted(suite, t, at)
+	}
+}
+
+// commandFinished calls ctx.CommandFinished for tests that have a command.
+func (ctx TestContext) commandFinished(suite *TestSuite, t *Test, at time.Time) {
+	if ctx.CommandFinished != nil && !ctx.Debug && len(t.command) > 0 {
+		ctx.CommandFinished(suite, t, at)
+	}
+}
+
+// record records the results of the test's command from its output and
+// exit status. A command that got stuck has no status, and is reported
+// as having run out of time.
+func (t *Test) record(out []byte, status string, stuck bool) error {
+	lines := bytes.Split(out, []byte{'\n'})
+	if j := len(lines) - 1; len(lines[j]) != 0 {
+		lines[j] = append(lines[j], []byte(" (no-eol)")...)
+	} else {
+		lines = lines[:j]
+	}
+
+	var err error
+	if stuck {
+		t.exitStatus = -1
+		lines = append(lines, []byte("[timeout]"))
+	} else if t.exitStatus, err = strconv.Atoi(status); err != nil {
+		return fmt.Errorf("could not read test status: %s", err)
+	} else if t.exitStatus != 0 {
+		lines = append(lines, []byte("["+status+"]"))
+	}
+
+	t.output = out
+	t.obsResults = lines
+	if !t.filtered {
+		t.changes = Diff(t.expResults, t.obsResults)
+	}
+	return nil
+}
+
+// makeWorkDir creates a fresh working directory at path. If path is already
+// taken, e.g. because the same suite is run more than once, a uniquely named
+// sibling directory is created instead.
+func makeWorkDir(path string) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	err := os.Mkdir(path, 0700)
+	if err == nil {
+		return path, nil
+	}
+	if !os.IsExist(err) {
+		return "", err
+	}
+	return ioutil.TempDir(filepath.Dir(path), filepath.Base(path)+".")
+}
+
+// Run runs the entire suite within the TestContext. An non-nil error
+// indicates a failure to run the test. Use TestSuite.Failed() to find
+// out if the test ran but did not produce the expected output.
+//
+// At the end, Run prints suite status glyph to ctx.Stdout.
+func (suite *TestSuite) Run(ctx TestContext) error {
+	// Forget the results of any previous run
+	for i := range suite.Tests {
+		t := &suite.Tests[i]
+		t.obsResults, t.changes, t.output = nil, nil, nil
+		t.exitStatus, t.duration, t.skipped = 0, 0, false
+	}
+
+	// Add test specific variables
+	testdir, err := filepath.Abs(filepath.Dir(suite.Name))
+	if err != nil {
+		return err
+	}
+
+	// Update workdir for each individual test;
+	// OK to set fields since ctx is passed by value.
+	ctx.WorkDir, err = makeWorkDir(filepath.Join(ctx.WorkDir, strings.TrimPrefix(suite.Name, "/")))
+	if err != nil {
+		return err
+	}
+
+	// Temporary directory and paths for output / status
+	// Will contain:
+	//   * status - common status file, one line per test exit status code
+	//   * out.0, out.1, ... - output for each individual test command in a suite.
+	//   * started, done.0, done.1, ... - empty files marking that the shell
+	//     started running the commands and that each of them finished.
+	cmdDir := ctx.WorkDir + ".cmd"
+	if err := os.MkdirAll(cmdDir, 0700); err != nil {
+		return err
+	}
+
+	outBasePath := filepath.Join(cmdDir, "out")
+	startedPath := filepath.Join(cmdDir, "started")
+	doneBasePath := filepath.Join(cmdDir, "done")
+	statusPath := filepath.Join(cmdDir, "status")
+	statusCmd := fmt.Sprintf("_grill_status=$?\necho $_grill_status >>%s\n", statusPath)
+
+	// Following cram, a command exiting with skipStatus skips the rest
+	// of the suite.
+	skipCmd := fmt.Sprintf("test $_grill_status -ne %d || exit 0\n", skipStatus)
+
+	// Copy the environment so that suites running concurrently
+	// within the same context don't share a backing array.
+	ctx.Environ = append(ctx.Environ[:len(ctx.Environ):len(ctx.Environ)], []string{
+		// TODO escape spaces in paths?
+		fmt.Sprintf("TESTFILE=%s", filepath.Base(suite.Name)),
+		fmt.Sprintf("TESTDIR=%s", testdir),
+	}...)
+
+	// TODO use <testname>.cmd/in temporary file instead for easier debugging?
+	script := new(bytes.Buffer)
+	script.WriteString(fmt.Sprintf(": >%s\n", startedPath))
+	for i, t := range suite.Tests {
+		// Redirect pipes to dedicated output file for each test command.
+		// Write command status to a single status file.
+		if !ctx.Debug {
+			script.WriteString(fmt.Sprintf("exec >%s.%d 2>&1\n", outBasePath, i))
+		}
+		for _, line := range t.command {
+			script.Write(line)
+			script.WriteByte('\n')
+		}
+		script.WriteString(statusCmd)
+		script.WriteString(fmt.Sprintf(": >%s.%d\n", doneBasePath, i))
+		script.WriteString(skipCmd)
+	}
+
+	var shellOpts []string
+	if len(ctx.Shell) > 1 {
+		shellOpts = ctx.Shell[1:]
+	}
+	cmd := exec.Command(ctx.Shell[0], shellOpts...)
+	cmd.Stdin = script
+	cmd.Env = ctx.Environ
+	cmd.Dir = ctx.WorkDir
+	if ctx.Debug {
+		cmd.Stdout = ctx.Stdout
+		cmd.Stderr = ctx.Stderr
+	}
+	if ctx.Timeout > 0 || ctx.CommandTimeout > 0 || ctx.Cancel != nil {
+		setProcessGroup(cmd)
+	}
+
+	start := time.Now()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("couldn't run command: %s", err)
+	}
+
+	// Commands are reported as they are seen to start and finish.
+	// reported is the number of them whose start has been reported.
+	var (
+		reported int
+		last     time.Time
+		skipped  bool
+	)
+	mark := func(n int, at time.Time) {
+		if skipped || ctx.Debug {
+			return
+		}
+		if n > 0 {
+			t := &suite.Tests[n-1]
+			b, err := os.ReadFile(statusPath)
+			status := strings.Fields(string(b))
+			if err != nil || len(status) < n {
+				return
+			}
+			if status[n-1] == strconv.Itoa(skipStatus) {
+				// The rest of the suite is skipped.
+				skipped = true
+				return
+			}
+			out, err := os.ReadFile(fmt.Sprintf("%s.%d", outBasePath, n-1))
+			if err != nil || t.record(out, status[n-1], false) != nil {
+				return
+			}
+			t.duration = at.Sub(last)
+			ctx.commandFinished(suite, t, at)
+		}
+		last = at
+		if n < len(suite.Tests) {
+			ctx.commandStarted(suite, &suite.Tests[n], at)
+			reported = n + 1
+		}
+	}
+
+	exited := make(chan struct{})
+	watched := ctx.watch(cmd, startedPath, doneBasePath, start, exited, mark)
+
+	err = cmd.Wait()
+	killed := time.Now()
+	close(exited)
+	p := <-watched
+
+	// Index of the command that ran out of time, if any. That is the
+	// first one that didn't get to mark itself done, even if it has
+	// already reported its status.
+	stuck := -1
+	if p.timedOut {
+		err = nil
+		if len(p.finished) < len(suite.Tests) {
+			stuck = len(p.finished)
+		}
+	}
+
+	if err != nil {
+		// A command may exit the shell itself to skip the suite.
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == skipStatus {
+			suite.skip()
+			return nil
+		}
+		// Last command in each script is the separator that echos return code
+		// and so the script should always exit with zero. If it doesn't, then
+		// it likely exited prematurely (e.g. developer had set -e in it)
+		return fmt.Errorf("test exited with unexpected error: %s", err)
+	}
+
+	// Read the list of exit status codes
+	b, err := os.ReadFile(statusPath)
+	if err != nil && !(stuck >= 0 && os.IsNotExist(err)) {
+		// A suite killed during its first command
+		// reports no status at all.
+		return fmt.Errorf("could not read test status: %s", err)
+	}
+	status := strings.Fields(string(b))
+
+	if stuck >= 0 && len(status) > stuck {
+		// Only the statuses of the commands before the stuck one count.
+		status = status[:stuck]
+	}
+
+	if n := len(status); n > 0 && n <= len(suite.Tests) && status[n-1] == strconv.Itoa(skipStatus) {
+		suite.skip()
+		return nil
+	}
+
+	if stuck < 0 && len(status) != len(suite.Tests) {
+		return fmt.Errorf("no. of status codes does not match no. of tests (%d != %d)",
+			len(status), len(suite.Tests))
+	}
+
+	if ctx.Debug {
+		suite.skip()
+		return nil
+	}
+
+	for i := range suite.Tests {
+		t := &suite.Tests[i]
+
+		if i > stuck && stuck >= 0 {
+			// Never ran.
+			t.skipped = true
+			continue
+		}
+
+		// Test output and exit status
+		b, err := os.ReadFile(fmt.Sprintf("%s.%d", outBasePath, i))
+		if err != nil && !(i == stuck && os.IsNotExist(err)) {
+			return fmt.Errorf("could not read test output: %s", err)
+		}
+		var st string
+		if i != stuck {
+			st = status[i]
+		}
+		if err := t.record(b, st, i == stuck); err != nil {
+			return err
+		}
+
+		// Test duration
+		if i == stuck {
+			if !p.last().IsZero() {
+				start = p.last()
+			}
+			t.duration = killed.Sub(start)
+			continue
+		}
+		if i >= len(p.finished) {
+			return fmt.Errorf("could not read test duration: command %d never finished", i)
+		}
+		if i == 0 {
+			start = p.started
+		}
+		t.duration = p.finished[i].Sub(start)
+		start = p.finished[i]
+	}
+
+	if stuck >= 0 {
+		// The stuck command is only seen to finish once it's killed.
+		t := &suite.Tests[stuck]
+		if reported <= stuck {
+			ctx.commandStarted(suite, t, start)
+		}
+		ctx.commandFinished(suite, t, killed)
+	}
+
+	return nil
+}
+
+// RunCount runs the suite count times, each time in a fresh working
+// directory, and records the distinct outputs of every test whose output
+// varied between the runs. The results of the last run are kept.
+//
+// Tests whose output isn't compared, because they were filtered
+// out, are never considered to vary.
+func (suite *TestSuite) RunCount(ctx TestContext, count int) error {
+	variants := make([][]variant, len(suite.Tests))
+
+	for run := 1; run <= count; run++ {
+		if err := suite.Run(ctx); err != nil {
+			return err
+		}
+		for i, t := range suite.Tests {
+			variants[i] = addVariant(variants[i], t.obsResults, run)
+		}
+	}
+
+	for i := range suite.Tests {
+		t := &suite.Tests[i]
+		t.variants = nil
+		if len(variants[i]) > 1 && !t.filtered {
+			t.variants = variants[i]
+		}
+	}
+
+	return nil
+}
+
+// addVariant records lines produced by a run among the known variants.
+func addVariant(variants []variant, lines [][]byte, run int) []variant {
+	for i, v := range variants {
+		if len(v.lines) != len(lines) {
+			continue
+		}
+		same := true
+		for j := range lines {
+			if !bytes.Equal(v.lines[j], lines[j]) {
+				same = false
+				break
+			}
+		}
+		if same {
+			variants[i].runs = append(variants[i].runs, run)
+			return variants
+		}
+	}
+	return append(variants, variant{lines: lines, runs: []int{run}})
+}
+
+// progress is what is known of a run of a suite from watching it.
+type progress struct {
+	// started is when the shell was seen to start running the commands,
+	// and finished holds the times at which the commands were seen to
+	// be done, in order.
+	started  time.Time
+	finished []time.Time
+	// timedOut tells that the shell's process group was killed
+	// because the suite or a command ran out of time.
+	timedOut bool
+}
+
+// last returns the time at which the command following
+// the finished ones was seen to start.
+func (p *progress) last() time.Time {
+	if n := len(p.finished); n > 0 {
+		return p.finished[n-1]
+	}
+	return p.started
+}
+
+// watch polls for the files marking the progress of cmd, which started at
+// start, and kills its process group once the suite or the command it is
+// currently running run out of time, or once ctx.Cancel is closed. Closing
+// exited stops the watch, and the
+// returned channel then receives what was seen.
+//
+// mark is called with the number of commands that have finished each time
+// the shell is seen to make progress, starting with 0 when it starts running
+// the commands.
+//
+// Times are taken when the marks are seen rather than from the files, whose
+// modification times can be too coarse to time commands by.
+func (ctx TestContext) watch(cmd *exec.Cmd, startedPath, doneBasePath string, start time.Time, exited <-chan struct{}, mark func(n int, at time.Time)) <-chan progress {
+	result := make(chan progress, 1)
+
+	go func() {
+		var p progress
+
+		exists := func(path string) bool {
+			_, err := os.Stat(path)
+			return err == nil
+		}
+		poll := func(now time.Time) {
+			if p.started.IsZero() {
+				if !exists(startedPath) {
+					return
+				}
+				p.started = now
+				mark(0, now)
+			}
+			for exists(fmt.Sprintf("%s.%d", doneBasePath, len(p.finished))) {
+				p.finished = append(p.finished, now)
+				mark(len(p.finished), now)
+			}
+		}
+
+		var suiteDeadline <-chan time.Time
+		if ctx.Timeout > 0 {
+			timer := time.NewTimer(ctx.Timeout)
+			defer timer.Stop()
+			suiteDeadline = timer.C
+		}
+
+		ticker := time.NewTicker(progressPollInterval)
+		defer ticker.Stop()
+
+		cancel := ctx.Cancel
+		for {
+			select {
+			case <-exited:
+				poll(time.Now())
+				result <- p
+				return
+			case <-suiteDeadline:
+			case <-cancel:
+				// Closed channels are always ready.
+				cancel = nil
+			case now := <-ticker.C:
+				poll(now)
+				if ctx.CommandTimeout <= 0 {
+					continue
+				}
+				// Each command gets its own deadline, starting from
+				// the moment the preceding command finished.
+				last := p.last()
+				if last.IsZero() {
+					last = start
+				}
+				if now.Before(last.Add(ctx.CommandTimeout)) {
+					continue
+				}
+			}
+			if p.timedOut {
+				// Waiting for the shell to be reaped.
+				continue
+			}
+			select {
+			case <-exited:
+				// The shell finished just in time.
+				continue
+			default:
+			}
+			p.timedOut = true
+			_ = killProcessGroup(cmd)
+		}
+	}()
+
+	return result
+}
@@ -0,0 +1,318 @@
+package grill
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunSuite(t *testing.T) {
+	suite := &TestSuite{
+		Tests: []Test{
+			{
+				doc: [][]byte{
+					[]byte("This is a test"),
+				},
+				command: [][]byte{[]byte("echo foobar")},
+				expResults: [][]byte{
+					[]byte("foobar"),
+				},
+			},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		r := recover()
+		os.RemoveAll(dir)
+		if r != nil {
+			panic(r)
+		}
+	}()
+
+	ctx := TestContext{
+		Shell:   []string{"bash"},
+		WorkDir: dir,
+		Environ: os.Environ(),
+	}
+
+	if err := suite.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	test := &suite.Tests[0]
+
+	join := byteSlicesToString
+	if got, want := join(test.obsResults), join(test.expResults); got != want {
+		t.Errorf("bad test output: got %q, want %q", got, want)
+	}
+
+	if got, want := string(suite.StatusGlyph()), "."; got != want {
+		t.Errorf("bad status output: got %q, want %q", got, want)
+	}
+}
+
+func TestRunSuiteDebug(t *testing.T) {
+	suite := &TestSuite{
+		Tests: []Test{
+			{
+				command: [][]byte{[]byte("echo foo")},
+				expResults: [][]byte{
+					[]byte("bar"),
+				},
+			},
+			{
+				command: [][]byte{[]byte("echo baz >&2")},
+			},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	ctx := TestContext{
+		Shell:   []string{"bash"},
+		WorkDir: dir,
+		Environ: os.Environ(),
+		Debug:   true,
+		Stdout:  stdout,
+		Stderr:  stderr,
+	}
+
+	if err := suite.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := stdout.String(), "foo\n"; got != want {
+		t.Errorf("bad stdout: got %q, want %q", got, want)
+	}
+	if got, want := stderr.String(), "baz\n"; got != want {
+		t.Errorf("bad stderr: got %q, want %q", got, want)
+	}
+	if got, want := suite.StatusGlyph(), "s"; got != want {
+		t.Errorf("bad status output: got %q, want %q", got, want)
+	}
+}
+
+func TestRunSuiteCount(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	suite := &TestSuite{
+		Name: "count.t",
+		Tests: []Test{
+			{command: [][]byte{[]byte("echo foo")}, expResults: [][]byte{[]byte("foo")}},
+			{command: [][]byte{[]byte("echo x >>$COUNTER; wc -l <$COUNTER | tr -d ' '")}},
+		},
+	}
+
+	ctx := TestContext{
+		Shell:   []string{"bash"},
+		WorkDir: dir,
+		Environ: append(os.Environ(), "COUNTER="+dir+"/counter"),
+	}
+
+	if err := suite.RunCount(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if suite.Tests[0].Flaky() {
+		t.Error("stable test is flaky")
+	}
+	if !suite.Tests[1].Flaky() {
+		t.Error("flaky test is not flaky")
+	}
+	if got, want := suite.Status(), "flaky"; got != want {
+		t.Errorf("bad status: got %q, want %q", got, want)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := suite.WriteVariants(buf, 2); err != nil {
+		t.Fatal(err)
+	}
+	want := `--- count.t (flaky)
+  $ echo x >>$COUNTER; wc -l <$COUNTER | tr -d ' '
+run 1:
+  1
+run 2:
+  2
+run 3:
+  3
+`
+	if got := buf.String(); got != want {
+		t.Errorf("bad variants: got %q, want %q", got, want)
+	}
+}
+
+func TestRunSuiteKilledBeforeDone(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The second command reports a status of its own before hanging,
+	// like a command killed right after its status was recorded.
+	suite := &TestSuite{
+		Name: "killed.t",
+		Tests: []Test{
+			{command: [][]byte{[]byte("echo foo")}},
+			{command: [][]byte{[]byte("echo 0 >>$PWD.cmd/status; sleep 60")}},
+			{command: [][]byte{[]byte("echo not reached")}},
+		},
+	}
+
+	ctx := TestContext{
+		Shell:          []string{"bash"},
+		WorkDir:        dir,
+		Environ:        os.Environ(),
+		CommandTimeout: time.Second,
+	}
+
+	if err := suite.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	join := byteSlicesToString
+	if got, want := join(suite.Tests[0].obsResults), "foo"; got != want {
+		t.Errorf("bad output of the first command: got %q, want %q", got, want)
+	}
+	if got, want := join(suite.Tests[1].obsResults), "[timeout]"; got != want {
+		t.Errorf("bad output of the stuck command: got %q, want %q", got, want)
+	}
+	if !suite.Tests[2].Skipped() {
+		t.Error("command after the stuck one wasn't skipped")
+	}
+}
+
+func TestRunSuiteCancel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	suite := &TestSuite{
+		Name: "cancel.t",
+		Tests: []Test{
+			{command: [][]byte{[]byte("echo foo")}},
+			{command: [][]byte{[]byte("sleep 60")}},
+		},
+	}
+
+	cancel := make(chan struct{})
+	ctx := TestContext{
+		Shell:   []string{"bash"},
+		WorkDir: dir,
+		Environ: os.Environ(),
+		Cancel:  cancel,
+		CommandStarted: func(suite *TestSuite, t *Test, at time.Time) {
+			if t == &suite.Tests[1] {
+				close(cancel)
+			}
+		},
+	}
+
+	start := time.Now()
+	if err := suite.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("cancelled suite took %s to finish", elapsed)
+	}
+
+	join := byteSlicesToString
+	if got, want := join(suite.Tests[0].obsResults), "foo"; got != want {
+		t.Errorf("bad output of the first command: got %q, want %q", got, want)
+	}
+	if got, want := join(suite.Tests[1].obsResults), "[timeout]"; got != want {
+		t.Errorf("bad output of the cancelled command: got %q, want %q", got, want)
+	}
+}
+
+func TestRunSuiteDurations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	suite := &TestSuite{
+		Name: "durations.t",
+		Tests: []Test{
+			{command: [][]byte{[]byte("sleep 0.3")}},
+			{command: [][]byte{[]byte("true")}},
+		},
+	}
+
+	ctx := TestContext{
+		Shell:   []string{"bash"},
+		WorkDir: dir,
+		Environ: os.Environ(),
+	}
+
+	if err := suite.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// Commands are timed as precisely as they are polled for.
+	if d := suite.Tests[0].Duration(); d < 300*time.Millisecond-progressPollInterval || d > 2*time.Second {
+		t.Errorf("bad duration of the first command: %s", d)
+	}
+	if d := suite.Tests[1].Duration(); d >= 300*time.Millisecond {
+		t.Errorf("bad duration of the second command: %s", d)
+	}
+}
+
+func TestRunSuiteCallbacks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	suite := &TestSuite{
+		Name: "callbacks.t",
+		Tests: []Test{
+			{command: [][]byte{[]byte("echo foo")}, expResults: [][]byte{[]byte("foo")}},
+			{command: [][]byte{[]byte("sleep 60")}},
+			{doc: [][]byte{[]byte("Trailing doc")}},
+		},
+	}
+
+	var calls []string
+	ctx := TestContext{
+		Shell:          []string{"bash"},
+		WorkDir:        dir,
+		Environ:        os.Environ(),
+		CommandTimeout: time.Second,
+		CommandStarted: func(s *TestSuite, test *Test, at time.Time) {
+			calls = append(calls, "start "+string(test.command[0]))
+		},
+		CommandFinished: func(s *TestSuite, test *Test, at time.Time) {
+			calls = append(calls, "finish "+string(test.command[0])+": "+byteSlicesToString(test.obsResults))
+		},
+	}
+
+	if err := suite.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "start echo foo\nfinish echo foo: foo\nstart sleep 60\nfinish sleep 60: [timeout]"
+	if got := strings.Join(calls, "\n"); got != want {
+		t.Errorf("bad calls: got %q, want %q", got, want)
+	}
+}
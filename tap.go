@@ -0,0 +1,124 @@
+package grill
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TAPOptions control the contents of the report written by WriteTAP.
+type TAPOptions struct {
+	// Shuffled tells that suites ran in random order, seeded with Seed.
+	Shuffled bool
+	Seed     int64
+}
+
+// WriteTAP writes out a Test Anything Protocol report on the overall grill run.
+//
+// Each command is reported as a test point. Failed test points are followed
+// by a YAML diagnostic block holding the expected and observed output lines.
+// Commands that didn't run, or whose output wasn't compared, are marked SKIP.
+// The seed of a shuffled run is given in a comment following the plan.
+func WriteTAP(w io.Writer, suites []*TestSuite, opts TAPOptions) error {
+	points := 0
+	for _, s := range suites {
+		for _, t := range s.Tests {
+			if len(t.command) > 0 {
+				points++
+			}
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "TAP version 13\n1..%d\n", points); err != nil {
+		return err
+	}
+	if opts.Shuffled {
+		if _, err := fmt.Fprintf(w, "# Shuffled with seed %d.\n", opts.Seed); err != nil {
+			return err
+		}
+	}
+
+	n := 0
+	for _, s := range suites {
+		for i := range s.Tests {
+			t := &s.Tests[i]
+			if len(t.command) == 0 {
+				continue
+			}
+			n++
+
+			desc := strings.ReplaceAll(fmt.Sprintf("%s: %s", s.Name, t.command[0]), "#", `\#`)
+			var err error
+			switch {
+			case s.NotRun:
+				_, err = fmt.Fprintf(w, "ok %d - %s # SKIP not run\n", n, desc)
+			case t.Skipped():
+				_, err = fmt.Fprintf(w, "ok %d - %s # SKIP\n", n, desc)
+			case t.Filtered():
+				_, err = fmt.Fprintf(w, "ok %d - %s # SKIP filtered\n", n, desc)
+			case t.Failed(), t.Flaky():
+				if _, err = fmt.Fprintf(w, "not ok %d - %s\n", n, desc); err == nil {
+					err = writeTAPDiagnostic(w, s, t)
+				}
+			default:
+				_, err = fmt.Fprintf(w, "ok %d - %s\n", n, desc)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeTAPDiagnostic writes the YAML diagnostic block of a failed test point.
+func writeTAPDiagnostic(w io.Writer, s *TestSuite, t *Test) error {
+	if _, err := io.WriteString(w, "  ---\n"); err != nil {
+		return err
+	}
+	if line := t.CommandLine(); line > 0 {
+		if _, err := fmt.Fprintf(w, "  at: %s\n", yamlQuote(fmt.Sprintf("%s:%d", s.Name, line))); err != nil {
+			return err
+		}
+	}
+	if err := writeYAMLLines(w, "expected", t.expResults); err != nil {
+		return err
+	}
+	if err := writeYAMLLines(w, "observed", t.obsResults); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "  ...\n")
+	return err
+}
+
+// writeYAMLLines writes lines as a YAML sequence of double-quoted strings.
+func writeYAMLLines(w io.Writer, key string, lines [][]byte) error {
+	if len(lines) == 0 {
+		_, err := fmt.Fprintf(w, "  %s: []\n", key)
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "  %s:\n", key); err != nil {
+		return err
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(w, "    - %s\n", yamlQuote(string(line))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// yamlQuote returns s as a YAML double-quoted scalar.
+func yamlQuote(s string) string {
+	// JSON strings are valid YAML double-quoted scalars.
+	buf := new(bytes.Buffer)
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		// Strings always encode.
+		panic(err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
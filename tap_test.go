@@ -0,0 +1,91 @@
+package grill
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestWriteTAP(t *testing.T) {
+	exp := [][]byte{[]byte("foo")}
+	obs := [][]byte{[]byte("bar \"baz\""), []byte("[1]")}
+
+	suites := []*TestSuite{
+		{
+			Name: "a.t",
+			Tests: []Test{
+				{
+					doc:        [][]byte{[]byte("Doc")},
+					command:    [][]byte{[]byte("echo foo # comment"), []byte("echo more")},
+					expResults: exp,
+					obsResults: exp,
+				},
+				{
+					command:    [][]byte{[]byte("echo bar")},
+					obsResults: obs,
+					changes:    Diff(nil, obs),
+				},
+				{
+					command:  [][]byte{[]byte("echo baz")},
+					filtered: true,
+				},
+				{
+					doc: [][]byte{[]byte("Trailing doc")},
+				},
+			},
+		},
+		{
+			Name: "b.t",
+			Tests: []Test{
+				{command: [][]byte{[]byte("true")}, skipped: true},
+			},
+		},
+		{
+			Name:   "c.t",
+			Tests:  []Test{{command: [][]byte{[]byte("true")}}},
+			NotRun: true,
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := WriteTAP(buf, suites, TAPOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `TAP version 13
+1..5
+ok 1 - a.t: echo foo \# comment
+not ok 2 - a.t: echo bar
+  ---
+  expected: []
+  observed:
+    - "bar \"baz\""
+    - "[1]"
+  ...
+ok 3 - a.t: echo baz # SKIP filtered
+ok 4 - b.t: true # SKIP
+ok 5 - c.t: true # SKIP not run
+`
+	if got := buf.String(); got != want {
+		t.Errorf("bad TAP report: got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteTAPShuffled(t *testing.T) {
+	suites := []*TestSuite{
+		{Name: "a.t", Tests: []Test{{command: [][]byte{[]byte("true")}}}},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := WriteTAP(buf, suites, TAPOptions{Shuffled: true, Seed: -42}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `TAP version 13
+1..1
+# Shuffled with seed -42.
+ok 1 - a.t: true
+`
+	if got := buf.String(); got != want {
+		t.Errorf("bad report: got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -2,5 +2,12 @@ Report test parsing errors:
 
   $ echo '  notacommand' > bad.t
   $ grill bad.t
-  ** syntax error parsing line 1: expected '$ ' after two spaces (glob)
+  ** bad.t: syntax error parsing line 1: expected '$ ' after two spaces (glob)
+  [1]
+
+Line numbers count from the start of the file:
+
+  $ printf '  $ true\n\nDoc\n  notacommand\n' > bad.t
+  $ grill bad.t
+  ** bad.t: syntax error parsing line 4: expected '$ ' after two spaces (glob)
   [1]
@@ -0,0 +1,73 @@
+A .grillrc file in the working directory or any of its parents
+provides default options and tests:
+
+  $ mkdir -p project/tests project/sub
+  $ cat > project/tests/a.t <<EOF
+  >   \$ echo \$0
+  >   /bin/bash
+  > EOF
+  $ cat > project/.grillrc <<EOF
+  > # Project defaults
+  > [grill]
+  > shell = /bin/bash
+  > tests = tests
+  > 
+  > [other]
+  > shell = /bin/false
+  > EOF
+
+  $ cd project/sub
+  $ grill -verbose
+  ../tests/a.t: passed
+  # Ran 1 test, 0 skipped, 0 failed.
+
+GRILL_OPTS overrides the configuration file and the command
+line overrides both:
+
+  $ GRILL_OPTS="-shell=/bin/sh -quiet" grill ../tests/a.t
+  !
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+
+  $ GRILL_OPTS="-shell=/bin/sh -quiet" grill -shell /bin/bash ../tests/a.t
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
+
+Lists given in one of these places replace those given
+in the places before it:
+
+  $ cat > ../tests/b.t <<EOF
+  >   \$ false
+  > EOF
+  $ printf '[grill]\nexclude = **/b.t\n' >> ../.grillrc
+  $ grill -verbose
+  ../tests/a.t: passed
+  # Ran 1 test, 0 skipped, 0 failed.
+  $ grill -verbose -quiet -exclude '**/a.t'
+  ../tests/b.t: failed
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+  $ GRILL_OPTS="-exclude **/a.t" grill -verbose -quiet
+  ../tests/b.t: failed
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+  $ GRILL_OPTS="-exclude **/a.t" grill -verbose -exclude '**/b.t'
+  ../tests/a.t: passed
+  # Ran 1 test, 0 skipped, 0 failed.
+
+  $ GRILL_OPTS="-quiet tests" grill
+  invalid GRILL_OPTS: unexpected argument "tests"
+  [2]
+  $ GRILL_OPTS=-bogus grill
+  invalid GRILL_OPTS: flag provided but not defined: -bogus
+  [2]
+
+  $ echo 'bogus = 1' > ../.grillrc
+  $ grill
+  **/project/.grillrc:1: unknown option "bogus" (glob)
+  [2]
+
+  $ echo 'jobs = many' > ../.grillrc
+  $ grill
+  **/project/.grillrc: invalid value "many" for flag -jobs: parse error (glob)
+  [2]
@@ -0,0 +1,85 @@
+-count runs each suite several times, each time in a fresh working
+directory, and reports commands whose output varies between runs:
+
+  $ mkdir sub
+  $ cat > sub/a.t <<EOF
+  > Stable:
+  > 
+  >   \$ echo foo
+  >   foo
+  > 
+  > Flaky:
+  > 
+  >   \$ test -e \$GRILLTMP/ran && echo again || touch \$GRILLTMP/ran
+  >   \$ ls
+  > EOF
+  $ echo '  $ true' > sub/b.t
+  $ touch .grillrc
+
+  $ grill -count 3 sub/*.t
+  ~.
+  sub/a.t:8: output varies between runs
+  --- sub/a.t (flaky)
+    $ test -e $GRILLTMP/ran && echo again || touch $GRILLTMP/ran
+  run 1:
+  runs 2, 3:
+    again
+  # Ran 2 tests, 0 skipped, 0 failed, 1 flaky.
+  [1]
+
+The results of the last run are kept:
+
+  $ cat sub/a.t.err
+  Stable:
+  
+    $ echo foo
+    foo
+  
+  Flaky:
+  
+    $ test -e $GRILLTMP/ran && echo again || touch $GRILLTMP/ran
+    again
+    $ ls
+
+Flaky suites are rerun by -failed:
+
+  $ grill -failed -verbose -quiet sub
+  sub/a.t: passed
+  # Ran 1 test, 0 skipped, 0 failed.
+
+Suites with a flaky command and a command that always fails are
+reported as both failed and flaky:
+
+  $ mkdir both
+  $ cat > both/c.t <<EOF
+  >   \$ test -e \$GRILLTMP/both && echo again || touch \$GRILLTMP/both
+  >   \$ echo bar
+  >   foo
+  > EOF
+  $ grill -count 2 both/c.t
+  !
+  both/c.t:2: output changed
+  --- both/c.t
+  +++ both/c.t.err
+  @@ -1,3 +1,4 @@
+     $ test -e $GRILLTMP/both && echo again || touch $GRILLTMP/both
+  +  again
+     $ echo bar
+  -  foo
+  +  bar
+  both/c.t:1: output varies between runs
+  --- both/c.t (flaky)
+    $ test -e $GRILLTMP/both && echo again || touch $GRILLTMP/both
+  run 1:
+  run 2:
+    again
+  # Ran 1 test, 0 skipped, 1 failed, 1 flaky.
+  [1]
+  $ grill -count 2 -verbose -quiet both/c.t
+  both/c.t: failed
+  # Ran 1 test, 0 skipped, 1 failed, 1 flaky.
+  [1]
+
+  $ grill -count 0 sub/*.t
+  -count must be >= 1
+  [2]
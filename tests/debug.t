@@ -0,0 +1,17 @@
+-debug writes command output directly to the terminal
+without comparing it:
+
+  $ cat > a.t <<EOF
+  >   \$ echo foo
+  >   bar
+  >   \$ echo baz >&2
+  > EOF
+
+  $ grill -debug a.t
+  foo
+  baz
+  s
+  # Ran 1 test, 1 skipped, 0 failed.
+
+  $ ls
+  a.t
@@ -0,0 +1,46 @@
+Directories are searched recursively for .t files, in sorted order,
+skipping hidden directories and .err files:
+
+  $ mkdir -p sub/b sub/a/fixtures sub/.hidden
+  $ echo '  $ true' > sub/z.t
+  $ echo '  $ true' > sub/b/pass.t
+  $ echo '  $ false' > sub/a/fail.t
+  $ echo '  $ true' > sub/a/fixtures/fixture.t
+  $ echo '  $ false' > sub/.hidden/hidden.t
+  $ echo 'not a test' > sub/b/README
+
+  $ grill -verbose -quiet sub
+  sub/a/fail.t: failed
+  sub/a/fixtures/fixture.t: passed
+  sub/b/pass.t: passed
+  sub/z.t: passed
+  # Ran 4 tests, 0 skipped, 1 failed.
+  [1]
+
+  $ ls sub/a/
+  fail.t
+  fail.t.err
+  fixtures
+
+  $ grill -verbose -quiet sub/a sub/z.t
+  sub/a/fail.t: failed
+  sub/a/fixtures/fixture.t: passed
+  sub/z.t: passed
+  # Ran 3 tests, 0 skipped, 1 failed.
+  [1]
+
+-exclude leaves out paths matching a glob pattern, either as a
+whole or by their base name:
+
+  $ grill -verbose -quiet -exclude fixtures -exclude 'sub/a/f*.t' sub
+  sub/b/pass.t: passed
+  sub/z.t: passed
+  # Ran 2 tests, 0 skipped, 0 failed.
+
+  $ grill -verbose -quiet -exclude '**/b/**' -exclude 'sub/a' sub
+  sub/z.t: passed
+  # Ran 1 test, 0 skipped, 0 failed.
+
+  $ grill sub/missing
+  ** stat sub/missing: no such file or directory (glob)
+  [1]
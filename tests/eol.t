@@ -0,0 +1,15 @@
+Line endings of test files are kept when changes are merged:
+
+  $ printf 'Doc\r\n  $ echo foo\r\n  bar\r\n\r\n  $ echo baz\r\n  baz' > a.t
+  $ grill -yes a.t > /dev/null
+  [1]
+  $ cat a.t | tr '\r' '~'
+  Doc~
+    $ echo foo~
+    foo~
+  ~
+    $ echo baz~
+    baz (no-eol)
+  $ grill a.t
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
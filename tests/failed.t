@@ -0,0 +1,89 @@
+The outcome of each suite is recorded next to the project's .grillrc,
+and -failed reruns only the suites that failed last time:
+
+  $ touch .grillrc
+  $ mkdir sub
+  $ echo '  $ true' > sub/a.t
+  $ echo '  $ false' > sub/b.t
+  $ echo '  $ false' > sub/c.t
+
+Without any record, -failed runs everything:
+
+  $ grill -failed -verbose -quiet sub
+  sub/a.t: passed
+  sub/b.t: failed
+  sub/c.t: failed
+  # Ran 3 tests, 0 skipped, 2 failed.
+  [1]
+
+Suites are recorded relative to the project:
+
+  $ cat .grillstate
+  passed\tsub/a.t (esc)
+  failed\tsub/b.t (esc)
+  failed\tsub/c.t (esc)
+
+  $ echo '  $ true' > sub/b.t
+  $ grill -failed -verbose -quiet sub
+  sub/b.t: passed
+  sub/c.t: failed
+  # Ran 2 tests, 0 skipped, 1 failed.
+  [1]
+
+so that they are matched when run from elsewhere:
+
+  $ (cd sub && grill -failed -verbose -quiet .)
+  c.t: failed
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+
+Suites that couldn't be run count as failed too:
+
+  $ echo '  $ true' > sub/c.t
+  $ echo '  notacommand' > sub/d.t
+  $ grill -quiet sub 2> /dev/null
+  [1]
+  $ grill -failed -verbose -quiet sub 2> /dev/null
+  [1]
+  $ grep d.t .grillstate
+  error\tsub/d.t (esc)
+  $ rm sub/d.t
+  $ grill -failed -verbose -quiet sub
+  sub/c.t: passed
+  # Ran 1 test, 0 skipped, 0 failed.
+  $ grill -failed -verbose -quiet sub
+  # Ran 0 tests, 0 skipped, 0 failed.
+
+Suites without a recorded outcome are run too:
+
+  $ mkdir new
+  $ echo '  $ false' > new/e.t
+  $ grill -failed -verbose -quiet new
+  new/e.t: failed
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+
+-state-dir sets where the outcomes are recorded:
+
+  $ mkdir state
+  $ grill -state-dir state -failed -quiet sub
+  ...
+  # Ran 3 tests, 0 skipped, 0 failed.
+  $ ls -A state
+  .grillstate
+
+Without a .grillrc or -state-dir, nothing is recorded,
+and -failed has nothing to go by:
+
+  $ mkdir -p elsewhere/sub
+  $ cp sub/a.t elsewhere/sub
+  $ cd elsewhere
+  $ rm ../.grillrc
+  $ grill -quiet sub
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
+  $ ls -A
+  sub
+  $ grill -failed sub
+  -failed needs -state-dir or a .grillrc file to record outcomes
+  [2]
@@ -0,0 +1,43 @@
+-failfast stops after the first failed suite; the remaining
+suites are reported as not run:
+
+  $ mkdir sub
+  $ echo '  $ true' > sub/a.t
+  $ echo '  $ false' > sub/b.t
+  $ echo '  $ false' > sub/c.t
+  $ echo 'No command' > sub/d.t
+  $ echo '  $ true' > sub/e.t
+
+  $ grill -quiet -failfast sub/*.t
+  .!
+  # Ran 2 tests, 0 skipped, 1 failed, 3 not run.
+  [1]
+
+With several jobs, suites that are already running when the limit
+is reached are left to finish and reported, but no more are started:
+
+  $ mkdir par
+  $ echo '  $ false' > par/a.t
+  $ echo '  $ sleep 3' > par/b.t
+  $ echo '  $ true' > par/c.t
+  $ echo '  $ true' > par/d.t
+  $ grill -quiet -jobs 2 -failfast par/*.t
+  !.
+  # Ran 2 tests, 0 skipped, 1 failed, 2 not run.
+  [1]
+
+-max-failures stops after the given number of failed suites:
+
+  $ grill -quiet -max-failures 2 sub/*.t
+  .!!
+  # Ran 3 tests, 0 skipped, 2 failed, 2 not run.
+  [1]
+
+  $ grill -quiet -max-failures 3 sub/*.t
+  .!!s.
+  # Ran 5 tests, 1 skipped, 2 failed.
+  [1]
+
+  $ grill -max-failures -1 sub/*.t
+  -max-failures must be >= 0
+  [2]
@@ -0,0 +1,42 @@
+-indent sets the indentation of commands and output:
+
+  $ cat > a.t <<EOF
+  > Four spaces:
+  > 
+  >     \$ echo foo
+  >     > echo bar
+  >     foo
+  >     baz
+  > EOF
+
+  $ grill -indent 4 a.t
+  !
+  a.t:3: output changed
+  --- a.t
+  +++ a.t.err
+  @@ -3,4 +3,4 @@
+       $ echo foo
+       > echo bar
+       foo
+  -    baz
+  +    bar
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+
+  $ cat a.t.err
+  Four spaces:
+  
+      $ echo foo
+      > echo bar
+      foo
+      bar
+
+  $ grill -indent 4 -yes a.t > /dev/null
+  [1]
+  $ grill -indent 4 a.t
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
+
+  $ grill -indent 0 a.t
+  -indent must be >= 1
+  [2]
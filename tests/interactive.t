@@ -0,0 +1,48 @@
+-interactive shows the diff of each failed suite and asks
+whether to merge the observed output back into the test:
+
+  $ cat > a.t <<EOF
+  > Doc lines are kept:
+  > 
+  >   \$ printf 'foo\n1\n'
+  >   bar
+  >   \d (re)
+  > EOF
+
+  $ echo n | grill -interactive a.t
+  !
+  --- a.t
+  +++ a.t.err
+  @@ -1,5 +1,5 @@
+   Doc lines are kept:
+   
+     $ printf 'foo\n1\n'
+  -  bar
+  +  foo
+     \d (re)
+  Accept this change? [yN] 
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+
+  $ ls
+  a.t
+  a.t.err
+
+  $ echo y | grill -interactive a.t > /dev/null
+  [1]
+
+  $ ls
+  a.t
+
+Matching lines, like regular expressions, are left untouched:
+
+  $ cat a.t
+  Doc lines are kept:
+  
+    $ printf 'foo\n1\n'
+    foo
+    \d (re)
+
+  $ grill -interactive a.t
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
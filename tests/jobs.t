@@ -0,0 +1,33 @@
+-jobs runs suites concurrently, but reports them in argument order:
+
+  $ mkdir sub
+  $ echo '  $ sleep 1' > sub/a.t
+  $ echo '  $ false' > sub/b.t
+  $ echo '  $ true' > sub/c.t
+  $ echo 'No command' > sub/d.t
+
+  $ grill -jobs 4 -quiet sub/*.t
+  .!.s
+  # Ran 4 tests, 1 skipped, 1 failed.
+  [1]
+
+  $ grill -jobs 4 -verbose -quiet sub/*.t
+  sub/a.t: passed
+  sub/b.t: failed
+  sub/c.t: passed
+  sub/d.t: skipped
+  # Ran 4 tests, 1 skipped, 1 failed.
+  [1]
+
+Error files are written for failed suites only:
+
+  $ ls sub/
+  a.t
+  b.t
+  b.t.err
+  c.t
+  d.t
+
+  $ grill -jobs 0 sub/a.t
+  -jobs must be >= 1
+  [2]
@@ -0,0 +1,55 @@
+-json prints one JSON event per line instead of the usual output:
+
+  $ cat > a.t <<'EOT'
+  >   $ echo foo
+  >   foo
+  >   $ echo bar; (exit 3)
+  >   foo
+  > EOT
+
+  $ grill -json a.t | sed 's/"Time":"[^"]*",//; s/,"Elapsed":[0-9.e-]*//'
+  {"Action":"start","Suite":"a.t"}
+  {"Action":"run","Suite":"a.t","Command":"echo foo","Line":1}
+  {"Action":"output","Suite":"a.t","Command":"echo foo","Line":1,"Output":"foo\n"}
+  {"Action":"pass","Suite":"a.t","Command":"echo foo","Line":1,"Exit":0}
+  {"Action":"run","Suite":"a.t","Command":"echo bar; (exit 3)","Line":3}
+  {"Action":"output","Suite":"a.t","Command":"echo bar; (exit 3)","Line":3,"Output":"bar\n"}
+  {"Action":"fail","Suite":"a.t","Command":"echo bar; (exit 3)","Line":3,"Exit":3}
+  {"Action":"fail","Suite":"a.t"}
+  $ ls
+  a.t
+  a.t.err
+
+Suites left out of the run are reported as not run, rather than skipped:
+
+  $ grill -json -failfast a.t a.t | grep -v '"Command"' | sed 's/"Time":"[^"]*",//; s/,"Elapsed":[0-9.e-]*//'
+  {"Action":"start","Suite":"a.t"}
+  {"Action":"fail","Suite":"a.t"}
+  {"Action":"notrun","Suite":"a.t"}
+
+Commands are reported as they run, before their suite has finished:
+
+  $ cat > live.t <<EOF
+  >   \$ echo first
+  >   first
+  >   \$ while test ! -e \$TESTDIR/go; do sleep 0.1; done
+  > EOF
+  $ grill -json -timeout 60s live.t > events &
+  $ for i in `seq 300`; do grep -q '"pass"' events && break; sleep 0.1; done
+  $ sed 's/"Time":"[^"]*",//; s/,"Elapsed":[0-9.e-]*//' events
+  {"Action":"start","Suite":"live.t"}
+  {"Action":"run","Suite":"live.t","Command":"echo first","Line":1}
+  {"Action":"output","Suite":"live.t","Command":"echo first","Line":1,"Output":"first\n"}
+  {"Action":"pass","Suite":"live.t","Command":"echo first","Line":1,"Exit":0}
+  {"Action":"run","Suite":"live.t","Command":"while test ! -e $TESTDIR/go; do sleep 0.1; done","Line":3}
+  $ touch go
+  $ wait
+  $ tail -n 2 events | sed 's/"Time":"[^"]*",//; s/,"Elapsed":[0-9.e-]*//'
+  {"Action":"pass","Suite":"live.t","Command":"while test ! -e $TESTDIR/go; do sleep 0.1; done","Line":3,"Exit":0}
+  {"Action":"pass","Suite":"live.t"}
+
+It can't be combined with options that print to stdout:
+
+  $ grill -json -yes a.t
+  -json can't be combined with -debug, -interactive, -yes or -no
+  [2]
@@ -0,0 +1,13 @@
+Lines of test files and command output may be arbitrarily long:
+
+  $ long=`head -c 200000 /dev/zero | tr '\0' x`
+  $ printf '  $ echo %s\n  %s\n' $long $long > long.t
+  $ grill long.t
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
+
+  $ printf '  $ echo %s\n  %sy\n' $long $long > long.t
+  $ grill -quiet long.t
+  !
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
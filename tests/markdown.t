@@ -0,0 +1,81 @@
+Markdown documents named on the command line are run, using shell
+sessions in indented code blocks and in ```console blocks:
+
+  $ cat > doc.md <<'EOT'
+  > # Usage
+  > 
+  >     $ echo foo
+  >     foo
+  > 
+  > ```console
+  > $ echo bar
+  > bar
+  > 
+  > $ echo baz
+  > baz
+  > ```
+  > 
+  > ```sh
+  > $ echo not run
+  > ```
+  > EOT
+  $ grill doc.md
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
+
+Failures produce a .md.err file and a diff:
+
+  $ sed 's/^baz$/qux/' doc.md > fail.md
+  $ grill fail.md
+  !
+  fail.md:10: output changed
+  --- fail.md
+  +++ fail.md.err
+  @@ -8,7 +8,7 @@
+   bar
+   
+   $ echo baz
+  -qux
+  +baz
+   ```
+   
+   ```sh
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+  $ mv fail.md.err fail.md
+  $ grill fail.md
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
+
+Output lines that would read back as something else are escaped:
+
+  $ cat > esc.md <<'EOT'
+  > ```console
+  > $ printf 'a\n\n'
+  > $ printf '$ b\n```\n'
+  > ```
+  > EOT
+  $ grill -yes -quiet esc.md
+  !
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+  $ sed 's/^/| /' esc.md
+  | ```console
+  | $ printf 'a\n\n'
+  | a
+  |  (esc)
+  | $ printf '$ b\n```\n'
+  | \x24 b (esc)
+  | \x60`` (esc)
+  | ```
+  $ grill esc.md
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
+
+Directories are only searched for .t files:
+
+  $ mkdir sub
+  $ cp fail.md sub
+  $ grill sub
+  
+  # Ran 0 tests, 0 skipped, 0 failed.
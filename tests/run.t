@@ -0,0 +1,33 @@
+-run selects suites by path and commands by their doc.
+Commands that aren't selected still run, but their output
+isn't compared:
+
+  $ mkdir sub
+  $ cat > sub/a.t <<EOF
+  > Setup:
+  > 
+  >   \$ X=foo
+  >   \$ echo unchecked
+  > 
+  > Check X:
+  > 
+  >   \$ echo \$X
+  >   foo
+  > EOF
+  $ echo '  $ false' > sub/b.t
+
+  $ grill -verbose -run 'Check' sub/*.t
+  sub/a.t: passed
+  # Ran 1 test, 0 skipped, 0 failed, 2 filtered.
+
+  $ grill -verbose -quiet -run 'b\.t$' sub/*.t
+  sub/b.t: failed
+  # Ran 1 test, 0 skipped, 1 failed.
+  [1]
+
+  $ grill -verbose -run 'nothing' sub/*.t
+  # Ran 0 tests, 0 skipped, 0 failed.
+
+  $ grill -run '(' sub/*.t
+  invalid -run: error parsing regexp: missing closing ): `(`
+  [2]
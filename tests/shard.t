@@ -0,0 +1,18 @@
+-shard runs a stable share of the test suites:
+
+  $ mkdir sub
+  $ for i in 1 2 3 4 5 6; do echo '  $ true' > sub/$i.t; done
+
+  $ grill -shard 1/2 -verbose sub > shard1
+  $ grill -shard 2/2 -verbose sub > shard2
+  $ cat shard1 shard2 | grep -c ': passed'
+  6
+  $ tail -2 shard2
+  # Shard 2/2.
+  # Ran * tests, 0 skipped, 0 failed. (glob)
+
+  $ grill -shard 1/2 -verbose sub | diff - shard1
+
+  $ grill -shard 3/2 sub
+  invalid -shard: expected 1 <= INDEX <= TOTAL
+  [2]
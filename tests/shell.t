@@ -17,3 +17,32 @@
   $ grill -shell=/bin/bash check-bash.t
   .
   # Ran 1 test, 0 skipped, 0 failed.
+
+-shell-opts sets the arguments the shell is invoked with,
+using shell quoting rules:
+
+  $ cat > check-opts.t <<EOF
+  >   $ echo \$-
+  >   *u* (glob)
+  >   $ false | true
+  >   [1]
+  > EOF
+
+  $ grill -shell=/bin/bash -shell-opts="-u -o 'pipefail'" -quiet check-opts.t
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
+
+  $ grill -shell-opts="'-x" check-opts.t
+  invalid -shell-opts: unterminated single quote
+  [2]
+
+TESTSHELL is set to the shell:
+
+  $ cat > check-testshell.t <<EOF
+  >   $ echo "\$TESTSHELL"
+  >   /bin/bash
+  > EOF
+
+  $ grill -shell=/bin/bash check-testshell.t
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
@@ -0,0 +1,49 @@
+-shuffle runs suites in random order and reports the seed,
+which -shuffle-seed takes to reproduce the order:
+
+  $ mkdir sub
+  $ for i in 1 2 3 4 5 6 7 8; do echo '  $ true' > sub/$i.t; done
+
+  $ grill -shuffle -verbose sub > log
+  $ tail -2 log
+  # Shuffled with seed * (glob)
+  # Ran 8 tests, 0 skipped, 0 failed.
+
+  $ seed=`grep -o '[0-9-]*\.$' log | tr -d .`
+  $ grill -shuffle-seed $seed -verbose sub | diff - log
+
+  $ grill -shuffle-seed 1 -verbose sub
+  sub/6.t: passed
+  sub/1.t: passed
+  sub/8.t: passed
+  sub/2.t: passed
+  sub/3.t: passed
+  sub/4.t: passed
+  sub/7.t: passed
+  sub/5.t: passed
+  # Shuffled with seed 1.
+  # Ran 8 tests, 0 skipped, 0 failed.
+
+A seed of 0 is a seed like any other:
+
+  $ grill -shuffle-seed 0 -verbose sub
+  sub/6.t: passed
+  sub/3.t: passed
+  sub/5.t: passed
+  sub/7.t: passed
+  sub/1.t: passed
+  sub/4.t: passed
+  sub/2.t: passed
+  sub/8.t: passed
+  # Shuffled with seed 0.
+  # Ran 8 tests, 0 skipped, 0 failed.
+
+The seed is also reported with -tap and -json:
+
+  $ grill -shuffle-seed 1 -tap sub | head -n 3
+  TAP version 13
+  1..8
+  # Shuffled with seed 1.
+
+  $ grill -shuffle-seed 1 -json sub | head -n 1 | sed 's/"Time":"[^"]*",//'
+  {"Action":"shuffle","Seed":1}
@@ -0,0 +1,30 @@
+A command exiting with status 80 skips the rest of the suite,
+regardless of the output of preceding commands:
+
+  $ mkdir sub
+  $ cat > sub/a.t <<EOF
+  >   \$ echo foo
+  >   bar
+  >   \$ test -x /no/such/tool || sh -c 'exit 80'
+  >   \$ touch not-reached
+  > EOF
+  $ echo '  $ true' > sub/b.t
+
+  $ grill sub/*.t
+  s.
+  # Ran 2 tests, 1 skipped, 0 failed.
+
+  $ grill -verbose sub/a.t
+  sub/a.t: skipped
+  # Ran 1 test, 1 skipped, 0 failed.
+
+  $ ls sub/
+  a.t
+  b.t
+
+Exiting the shell with status 80 skips the suite too:
+
+  $ printf '  $ echo foo\n  $ exit 80\n' > sub/c.t
+  $ grill sub/c.t
+  s
+  # Ran 1 test, 1 skipped, 0 failed.
@@ -0,0 +1,30 @@
+-tap prints a Test Anything Protocol report instead of the usual output:
+
+  $ cat > a.t <<'EOT'
+  >   $ echo foo
+  >   foo
+  >   $ echo bar
+  >   foo
+  > EOT
+  $ cat > b.t <<'EOT'
+  >   $ exit 80
+  > EOT
+
+  $ grill -tap a.t b.t
+  TAP version 13
+  1..3
+  ok 1 - a.t: echo foo
+  not ok 2 - a.t: echo bar
+    ---
+    at: "a.t:3"
+    expected:
+      - "foo"
+    observed:
+      - "bar"
+    ...
+  ok 3 - b.t: exit 80 # SKIP
+  [1]
+
+  $ grill -tap -json a.t
+  use of mutually exclusive -json and -tap
+  [2]
@@ -0,0 +1,62 @@
+-timeout kills a suite that runs for too long, along with everything
+it started, and reports the command that was running:
+
+  $ mkdir sub
+  $ cat > sub/a.t <<EOF
+  >   \$ echo foo
+  >   foo
+  >   \$ echo bar; sleep 60 & sleep 60
+  >   bar
+  >   \$ echo not reached
+  >   not reached
+  > EOF
+  $ echo '  $ true' > sub/b.t
+
+  $ grill -timeout 2s sub/*.t
+  !.
+  sub/a.t:3: output changed
+  --- sub/a.t
+  +++ sub/a.t.err
+  @@ -2,5 +2,6 @@
+     foo
+     $ echo bar; sleep 60 & sleep 60
+     bar
+  +  [timeout]
+     $ echo not reached
+     not reached
+  # Ran 2 tests, 0 skipped, 1 failed.
+  [1]
+
+-command-timeout limits each command separately, so that commands
+that take longer together than the limit still pass:
+
+  $ cat > sub/c.t <<EOF
+  >   \$ sleep 1.5
+  >   \$ sleep 1.5
+  >   \$ sleep 60
+  >   [timeout]
+  > EOF
+
+  $ grill -command-timeout 2.5s sub/c.t
+  .
+  # Ran 1 test, 0 skipped, 0 failed.
+
+The suites that follow keep running when the first command of a
+suite runs out of time:
+
+  $ cat > sub/d.t <<EOF
+  >   \$ sleep 60
+  >   \$ echo not reached
+  > EOF
+
+  $ grill -command-timeout 1s sub/d.t sub/b.t
+  !.
+  sub/d.t:1: output changed
+  --- sub/d.t
+  +++ sub/d.t.err
+  @@ -1,2 +1,3 @@
+     $ sleep 60
+  +  [timeout]
+     $ echo not reached
+  # Ran 2 tests, 0 skipped, 1 failed.
+  [1]
@@ -0,0 +1,83 @@
+-watch keeps running, rerunning suites as their files change and
+all of them when anything in a -watch-dir directory changes. ran
+waits until the log shows the given number of reports:
+
+  $ ran() {
+  >   for i in `seq 300`; do
+  >     test `grep -c "^# Ran" log` -ge $1 && return
+  >     sleep 0.1
+  >   done
+  >   return 1
+  > }
+
+  $ mkdir sub data
+  $ echo '  $ true' > sub/a.t
+  $ echo '  $ false' > sub/b.t
+
+  $ grill -watch -watch-interval 100ms -watch-dir data -verbose -quiet sub > log 2>&1 &
+  $ ran 1
+  $ echo '  $ true' > sub/b.t
+  $ ran 2
+  $ touch data/input
+  $ ran 3
+  $ kill $!
+
+  $ cat log
+  sub/a.t: passed
+  sub/b.t: failed
+  # Ran 2 tests, 0 skipped, 1 failed.
+  sub/b.t: passed
+  # Ran 1 test, 0 skipped, 0 failed.
+  sub/a.t: passed
+  sub/b.t: passed
+  # Ran 2 tests, 0 skipped, 0 failed.
+
+With -failed, only the suites that didn't pass last time are run,
+in every cycle:
+
+  $ touch .grillrc
+  $ echo '  $ false' > sub/b.t
+  $ grill -quiet sub
+  .!
+  # Ran 2 tests, 0 skipped, 1 failed.
+  [1]
+  $ grill -watch -watch-interval 100ms -watch-dir data -verbose -quiet -failed sub > log 2>&1 &
+  $ ran 1
+  $ touch data/input2
+  $ ran 2
+  $ kill $!
+
+  $ cat log
+  sub/b.t: failed
+  # Ran 1 test, 0 skipped, 1 failed.
+  sub/b.t: failed
+  # Ran 1 test, 0 skipped, 1 failed.
+  $ rm .grillrc .grillstate
+
+Watching stops on interrupt, cleaning up after itself:
+
+  $ echo '  $ true' > sub/b.t
+  $ grill -watch -watch-interval 100ms -keep-tmpdir sub > log 2>&1 &
+  $ ran 1
+  $ kill -INT $!
+  $ wait $!
+  $ cat log
+  ..
+  # Ran 2 tests, 0 skipped, 0 failed.
+  # Kept temporary directory: **/grilltests*/** (glob)
+
+Suites that are running when interrupted are killed, and
+those that haven't started are left out:
+
+  $ mkdir slow
+  $ cat > slow/a.t <<EOF
+  >   \$ touch \$TESTDIR/running; sleep 60
+  > EOF
+  $ echo '  $ true' > slow/b.t
+  $ grill -watch -quiet slow > log 2>&1 &
+  $ for i in `seq 300`; do test -e slow/running && break; sleep 0.1; done
+  $ kill -INT $!
+  $ wait $!
+  $ cat log
+  !
+  # Ran 1 test, 0 skipped, 1 failed, 1 not run.
@@ -0,0 +1,28 @@
+-xunit-file writes a JUnit compatible XML report:
+
+  $ mkdir sub
+  $ printf '  $ echo foo\n  foo\n  $ echo bar\n  baz\n' > sub/a.t
+  $ echo 'No command' > sub/b.t
+
+  $ grill -quiet -xunit-file report.xml sub/*.t
+  !s
+  # Ran 2 tests, 1 skipped, 1 failed.
+  [1]
+
+  $ cat report.xml
+  <?xml version="1.0" encoding="UTF-8"?>
+  <testsuites>
+    <testsuite name="sub/a.t" tests="2" failures="1" skipped="0" time="*.*"> (glob)
+      <testcase classname="sub/a.t" name="echo foo" file="sub/a.t" line="1" time="*.*"></testcase> (glob)
+      <testcase classname="sub/a.t" name="echo bar" file="sub/a.t" line="3" time="*.*"> (glob)
+        <failure message="output changed"><![CDATA[@@ -1,4 +1,4 @@
+     $ echo foo
+     foo
+     $ echo bar
+  -  baz
+  +  bar
+  ]]></failure>
+      </testcase>
+    </testsuite>
+    <testsuite name="sub/b.t" tests="0" failures="0" skipped="0" time="0.000"></testsuite>
+  </testsuites>
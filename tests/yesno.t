@@ -0,0 +1,71 @@
+-no declines every merge without writing .err files:
+
+  $ mkdir sub
+  $ printf '  $ echo foo\n  bar\n' > sub/a.t
+  $ printf 'Doc\n  $ echo 1\n  \\d (re)\n  $ echo 2\n  3\n' > sub/b.t
+  $ echo '  $ true' > sub/c.t
+
+  $ grill -no sub/*.t
+  !
+  --- sub/a.t
+  +++ sub/a.t.err
+  @@ -1,2 +1,2 @@
+     $ echo foo
+  -  bar
+  +  foo
+  Accept this change? [yN] n
+  !
+  --- sub/b.t
+  +++ sub/b.t.err
+  @@ -2,4 +2,4 @@
+     $ echo 1
+     \d (re)
+     $ echo 2
+  -  3
+  +  2
+  Accept this change? [yN] n
+  .
+  # Ran 3 tests, 0 skipped, 2 failed.
+  [1]
+
+  $ ls sub/
+  a.t
+  b.t
+  c.t
+
+With -quiet, the diffs and questions are left out:
+
+  $ grill -no -quiet sub/*.t
+  !!.
+  # Ran 3 tests, 0 skipped, 2 failed.
+  [1]
+
+-yes merges the observed output of every failed suite,
+keeping doc lines, commands and matching lines as written:
+
+  $ grill -yes -quiet sub/*.t
+  !!.
+  # Ran 3 tests, 0 skipped, 2 failed.
+  [1]
+
+  $ ls sub/
+  a.t
+  b.t
+  c.t
+
+  $ cat sub/b.t
+  Doc
+    $ echo 1
+    \d (re)
+    $ echo 2
+    2
+
+  $ grill sub/*.t
+  ...
+  # Ran 3 tests, 0 skipped, 0 failed.
+
+-yes and -no are mutually exclusive:
+
+  $ grill -yes -no sub/*.t
+  use of mutually exclusive -yes and -no
+  [2]
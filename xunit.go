@@ -0,0 +1,115 @@
+package grill
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+type xunitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []xunitTestSuite `xml:"testsuite"`
+}
+
+type xunitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []xunitTestCase `xml:"testcase"`
+}
+
+type xunitTestCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Time      string        `xml:"time,attr"`
+	Skipped   *struct{}     `xml:"skipped"`
+	Failure   *xunitFailure `xml:"failure"`
+}
+
+type xunitFailure struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",cdata"`
+}
+
+// WriteXUnit writes out a JUnit compatible XML report on the overall grill run.
+//
+// Each suite is reported as a testsuite element and each command as a testcase
+// element. Failed testcases hold the diff of the command's output, with ctxLen
+// lines of context and commands and output indented with indent spaces.
+func WriteXUnit(w io.Writer, suites []*TestSuite, ctxLen int, indent int) error {
+	var report xunitTestSuites
+
+	for _, s := range suites {
+		if s.NotRun {
+			continue
+		}
+		xs := xunitTestSuite{Name: s.Name}
+		var total time.Duration
+
+		for i := range s.Tests {
+			t := &s.Tests[i]
+			if len(t.command) == 0 {
+				continue
+			}
+			total += t.duration
+
+			xc := xunitTestCase{
+				Classname: s.Name,
+				Name:      string(t.command[0]),
+				File:      s.Name,
+				Line:      t.CommandLine(),
+				Time:      xunitTime(t.duration),
+			}
+			if t.Skipped() || t.Filtered() {
+				xs.Skipped++
+				xc.Skipped = &struct{}{}
+			} else if t.Flaky() {
+				xs.Failures++
+				variants := new(bytes.Buffer)
+				if err := t.writeVariants(variants, indent); err != nil {
+					return err
+				}
+				xc.Failure = &xunitFailure{
+					Message: "output varies between runs",
+					Body:    variants.String(),
+				}
+			} else if t.Failed() {
+				xs.Failures++
+				diff := new(bytes.Buffer)
+				if err := s.writeTestDiff(diff, i, ctxLen, indent); err != nil {
+					return err
+				}
+				xc.Failure = &xunitFailure{
+					Message: "output changed",
+					Body:    diff.String(),
+				}
+			}
+			xs.Cases = append(xs.Cases, xc)
+		}
+
+		xs.Tests = len(xs.Cases)
+		xs.Time = xunitTime(total)
+		report.Suites = append(report.Suites, xs)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func xunitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
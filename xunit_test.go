@@ -0,0 +1,111 @@
+package grill
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteXUnit(t *testing.T) {
+	exp := [][]byte{[]byte("foo")}
+	obs := [][]byte{[]byte("bar")}
+
+	suites := []*TestSuite{
+		{
+			Name: "a.t",
+			Tests: []Test{
+				{
+					line:       1,
+					doc:        [][]byte{[]byte("Doc & more")},
+					command:    [][]byte{[]byte("echo foo"), []byte("echo <bar>")},
+					cmdLines:   []int{2, 3},
+					expResults: exp,
+					obsResults: exp,
+					duration:   1500 * time.Millisecond,
+				},
+				{
+					line:       4,
+					command:    [][]byte{[]byte("echo bar")},
+					cmdLines:   []int{4},
+					expResults: exp,
+					obsResults: obs,
+					changes:    Diff(exp, obs),
+					duration:   time.Millisecond,
+				},
+				{
+					line: 6,
+					doc:  [][]byte{[]byte("Trailing doc")},
+				},
+			},
+		},
+		{
+			Name: "b.t",
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := WriteXUnit(buf, suites, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="a.t" tests="2" failures="1" skipped="0" time="1.501">
+    <testcase classname="a.t" name="echo foo" file="a.t" line="2" time="1.500"></testcase>
+    <testcase classname="a.t" name="echo bar" file="a.t" line="4" time="0.001">
+      <failure message="output changed"><![CDATA[@@ -5,3 +5,3 @@
+   $ echo bar
+-  foo
++  bar
+ Trailing doc
+]]></failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="b.t" tests="0" failures="0" skipped="0" time="0.000"></testsuite>
+</testsuites>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("bad xunit report: got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteXUnitFlaky(t *testing.T) {
+	suites := []*TestSuite{
+		{
+			Name: "a.t",
+			Tests: []Test{
+				{
+					command:    [][]byte{[]byte("date")},
+					obsResults: [][]byte{[]byte("Tue")},
+					variants: []variant{
+						{lines: [][]byte{[]byte("Mon")}, runs: []int{1}},
+						{lines: [][]byte{[]byte("Tue")}, runs: []int{2, 3}},
+					},
+				},
+			},
+		},
+	}
+
+	buf := new(bytes.Buffer)
+	if err := WriteXUnit(buf, suites, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="a.t" tests="1" failures="1" skipped="0" time="0.000">
+    <testcase classname="a.t" name="date" file="a.t" time="0.000">
+      <failure message="output varies between runs"><![CDATA[  $ date
+run 1:
+  Mon
+runs 2, 3:
+  Tue
+]]></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("bad xunit report: got\n%s\nwant\n%s", got, want)
+	}
+}